@@ -5,23 +5,38 @@ import (
 	"io"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/Sarthak-D97/go_stuAPI/controller"
+	"github.com/Sarthak-D97/go_stuAPI/entity"
 	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
 	db "github.com/Sarthak-D97/go_stuAPI/internal/platform/db"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/health"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/lockout"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/mail"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/oidc"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/ratelimit"
 	redisclient "github.com/Sarthak-D97/go_stuAPI/internal/platform/redis"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
 	"github.com/Sarthak-D97/go_stuAPI/middlewares"
 	"github.com/Sarthak-D97/go_stuAPI/repository"
 	studentRepoImpl "github.com/Sarthak-D97/go_stuAPI/repository"
 	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/Sarthak-D97/go_stuAPI/validators"
+	"github.com/Sarthak-D97/go_stuAPI/web"
 
 	"github.com/gin-gonic/gin"
-	gindump "github.com/tpkeeper/gin-dump"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 
 	// --- SWAGGER IMPORTS ---
 	swaggerFiles "github.com/swaggo/files"
@@ -31,9 +46,23 @@ import (
 	docs "github.com/Sarthak-D97/go_stuAPI/docs"
 )
 
-func setupLogOutput() {
+// registerProbes adds the liveness, readiness and startup probes.
+func registerProbes(router *gin.Engine, c controller.HealthController) {
+	router.GET("/healthz", c.Live)
+	router.GET("/readyz", c.Ready)
+	router.GET("/startupz", c.Startup)
+}
+
+// setupLogOutput sends gin's own output and the JSON application log to
+// stdout and gin.log, and makes the JSON logger the slog default.
+func setupLogOutput(cfg config.Logging) *slog.Logger {
 	f, _ := os.Create("gin.log")
-	gin.DefaultWriter = io.MultiWriter(f, os.Stdout)
+	out := io.MultiWriter(f, os.Stdout)
+	gin.DefaultWriter = out
+
+	logger := logging.New(out, cfg.Level)
+	slog.SetDefault(logger)
+	return logger
 }
 
 // @title           Student API
@@ -54,98 +83,463 @@ func setupLogOutput() {
 // @in header
 // @name Authorization
 func main() {
-	setupLogOutput()
 	cfg := config.MustLoad()
+	logger := setupLogOutput(cfg.Logging)
+
+	if len(cfg.BlockedWords) > 0 {
+		validators.SetBlockedWords(cfg.BlockedWords)
+	}
 
 	docs.SwaggerInfo.Host = cfg.HTTPServer.Addr
 	// If your config Addr is just ":8082", you might need to prepend localhost:
 	// docs.SwaggerInfo.Host = "localhost" + cfg.HTTPServer.Addr
 
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Tracing setup failed:", err)
+	}
+
+	// 0. Start listening with only the probes, so /startupz can report
+	// progress while databases connect and migrate.
+	healthChecker := health.New(cfg.Health.CheckTimeout)
+	healthController := controller.NewHealthController(healthChecker)
+
+	probes := gin.New()
+	probes.Use(gin.Recovery())
+	registerProbes(probes, healthController)
+	probes.NoRoute(func(ctx *gin.Context) {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service is starting"})
+	})
+
+	var handler atomic.Pointer[gin.Engine]
+	handler.Store(probes)
+
+	// Request contexts derive from baseCtx so in-flight queries can be
+	// cancelled when the shutdown timeout runs out.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	srv := &http.Server{
+		Addr: cfg.HTTPServer.Addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.Load().ServeHTTP(w, r)
+		}),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	go func() {
+		// Log the actual Swagger URL for convenience
+		slog.Info("Swagger UI is available at http://localhost" + cfg.HTTPServer.Addr + "/swagger/index.html")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	// Prometheus scrape endpoint (HTTP, GORM, Redis and Go runtime
+	// metrics) on its own listener, so it is not reachable from the
+	// public address.
+	var metricsSrv *http.Server
+	if cfg.HTTPServer.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", promhttp.Handler())
+		metricsSrv = &http.Server{Addr: cfg.HTTPServer.MetricsAddr, Handler: mux}
+		go func() {
+			slog.Info("Serving metrics on " + cfg.HTTPServer.MetricsAddr + "/metrics")
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("metrics listen: %s\n", err)
+			}
+		}()
+	}
+
 	// 1. Initialize Postgres
 	pgDB, err := db.NewPostgres(cfg)
 	if err != nil {
 		log.Fatal("Postgres setup failed:", err)
 	}
+	healthChecker.Register(health.Check{Name: "postgres", Critical: true, Run: db.Pinger(pgDB)})
+
+	// Records from before multi-tenancy belong to the default tenant.
+	tenantService := service.NewTenantService(repository.NewTenantRepository(pgDB), cfg.Tenancy.DefaultTenant)
+	defaultTenant, err := tenantService.EnsureDefault(context.Background())
+	if err != nil {
+		log.Fatal("Default tenant setup failed:", err)
+	}
+	if err := tenant.Adopt(context.Background(), pgDB, defaultTenant.ID, db.PostgresModels()...); err != nil {
+		log.Fatal("Tenant migration failed:", err)
+	}
+	tenantController := controller.NewTenantController(tenantService)
+
+	// 2. Initialize Cache (Redis is optional with the memory driver)
+	var rdb *redis.Client
+	if cfg.Cache.Driver != cache.DriverMemory {
+		rdb = redisclient.NewClient(cfg)
+		if err := redisclient.Ping(rdb, 2*time.Second); err != nil {
+			slog.Warn("Redis is not reachable, cache will degrade until it recovers", "error", err)
+		}
+		// The cache falls back to the database, so Redis only degrades readiness.
+		healthChecker.Register(health.Check{Name: "redis", Run: func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		}})
+	}
+	studentCache, err := cache.New(cfg.Cache, rdb)
+	if err != nil {
+		log.Fatal("Cache setup failed:", err)
+	}
 
-	// 2. Initialize Redis
-	rdb := redisclient.NewClient()
+	// Rate limits are shared through Redis whenever it is configured.
+	var limiter ratelimit.Limiter = ratelimit.NewMemory()
+	if rdb != nil {
+		limiter = ratelimit.NewRedis(rdb)
+	}
+	rateLimit := func(group string) gin.HandlerFunc {
+		var p config.RateLimitPolicy
+		if cfg.RateLimit.Enabled {
+			p = cfg.RateLimit.Policies[group]
+		}
+		return middlewares.RateLimit(limiter, group, ratelimit.Policy{Requests: p.Requests, Period: p.Period, Burst: p.Burst})
+	}
+
+	// Failed logins are counted in Redis too, so lockouts hold across replicas.
+	var lockouts lockout.Store = lockout.NewMemory()
+	if rdb != nil {
+		lockouts = lockout.NewRedis(rdb)
+	}
 
 	// 3. Initialize Services
 	jwtService := service.NewJWTService()
-	loginService := service.NewLoginService()
-	loginController := controller.NewLoginController(*loginService, jwtService)
-
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(pgDB))
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+	loginService := service.NewLoginService(cfg.Tenancy.DefaultTenant)
+	mailRenderer, err := mail.NewRenderer()
+	if err != nil {
+		log.Fatal("Mail template parsing failed:", err)
+	}
+	mailSender, err := mail.NewSMTP(mail.SMTPConfig{
+		Host:     cfg.Mail.SMTPHost,
+		Port:     cfg.Mail.SMTPPort,
+		Username: cfg.Mail.SMTPUsername,
+		Password: cfg.Mail.SMTPPassword,
+		TLS:      cfg.Mail.TLS,
+		From:     cfg.Mail.From,
+	})
+	if err != nil {
+		log.Fatal("Mail setup failed:", err)
+	}
+	outboxRepository := repository.NewOutboxRepository(pgDB)
+	mailDispatcher := service.NewMailDispatcher(outboxRepository, mailSender, cfg.Mail, logger)
 	studentRepo := studentRepoImpl.New(pgDB)
-	studentService := service.NewStudentService(studentRepo, rdb)
+	guardianRepository := repository.NewGuardianRepository(pgDB)
+	accountService := service.NewAccountService(repository.NewAccountRepository(pgDB), repository.NewUserRepository(pgDB), studentRepo, guardianRepository, outboxRepository, mailRenderer, cfg.Accounts, cfg.Mail.BaseURL)
+	accountController := controller.NewAccountController(accountService)
+	mfaService := service.NewMFAService(repository.NewMFARepository(pgDB), cfg.MFA.Issuer)
+	mfaController := controller.NewMFAController(mfaService)
+	authService := service.NewAuthService(loginService, accountService, mfaService, lockouts, repository.NewAuthRepository(pgDB), cfg.Lockout, cfg.MFA)
+	loginController := controller.NewLoginController(authService, jwtService)
+	authAdminController := controller.NewAuthAdminController(authService)
+
+	cacheStats := service.NewCacheStats()
+	lifecycle, err := service.NewLifecycle(cfg.Students.Transitions)
+	if err != nil {
+		log.Fatal("Invalid student transitions:", err)
+	}
+	studentService := service.NewStudentService(studentRepo, studentCache, cacheStats, cfg.Students.NumberFormat, lifecycle)
 	studentController := controller.NewStudentController(studentService)
+	// Students from before student numbers get one in their tenant's format.
+	allTenants, err := tenantService.List(context.Background())
+	if err != nil {
+		log.Fatal("Student number migration failed:", err)
+	}
+	for i := range allTenants {
+		if _, err := studentService.AssignNumbers(service.WithTenant(context.Background(), &allTenants[i])); err != nil {
+			log.Fatal("Student number migration failed:", err)
+		}
+	}
+	profileService := service.NewProfileService(repository.NewUserRepository(pgDB), guardianRepository, studentService)
+	profileController := controller.NewProfileController(profileService)
+	// Withdrawn students lose access to the portal.
+	lifecycle.OnEnter(entity.EnrollmentWithdrawn, service.DetachAccount(profileService))
+	guardianService := service.NewGuardianService(guardianRepository, studentRepo, repository.NewUserRepository(pgDB))
+	guardianController := controller.NewGuardianController(guardianService)
+
+	cacheAdminService := service.NewCacheAdminService(studentCache, cacheStats, studentService)
+	cacheAdminController := controller.NewCacheAdminController(cacheAdminService)
 
-	videoRepository := repository.NewVideoRepository()
+	videoDB, err := db.NewSQLite("test.db")
+	if err != nil {
+		log.Fatal("SQLite setup failed:", err)
+	}
+	healthChecker.Register(health.Check{Name: "video_store", Critical: true, Run: db.Pinger(videoDB)})
+	if err := tenant.Adopt(context.Background(), videoDB, defaultTenant.ID, db.SQLiteModels()...); err != nil {
+		log.Fatal("Tenant migration failed:", err)
+	}
+	videoRepository := repository.NewVideoRepository(videoDB)
 	defer videoRepository.CloseDB()
 
-	videoService := service.NewVideoService(videoRepository)
+	personRepository := repository.NewPersonRepository(videoDB)
+	personService := service.NewPersonService(personRepository, videoRepository)
+	personController := controller.NewPersonController(personService)
+
+	taxonomyRepository := repository.NewTaxonomyRepository(videoDB)
+	taxonomyService := service.NewTaxonomyService(taxonomyRepository)
+	taxonomyController := controller.NewTaxonomyController(taxonomyService)
+
+	videoService := service.NewVideoService(videoRepository, personService, taxonomyService)
 	videoController := controller.New(videoService)
 
+	feedbackRepository := repository.NewFeedbackRepository(videoDB)
+	feedbackService := service.NewFeedbackService(feedbackRepository, videoService, studentService)
+	feedbackController := controller.NewFeedbackController(feedbackService, profileService)
+	// Ratings and comments live in SQLite, so a merge moves them separately.
+	studentService.OnMerge(feedbackService.MoveStudent)
+
+	sessions := middlewares.NewSessionManager(service.GetSecretKey(), 12*time.Hour)
+	oidcClient := oidc.New(oidc.Config{
+		Issuer:       cfg.OIDC.Issuer,
+		ClientID:     cfg.OIDC.ClientID,
+		ClientSecret: cfg.OIDC.ClientSecret,
+		RedirectURL:  cfg.OIDC.RedirectURL,
+		Scopes:       cfg.OIDC.Scopes,
+		GroupsClaim:  cfg.OIDC.GroupsClaim,
+	}, nil)
+	oidcService := service.NewOIDCService(oidcClient, repository.NewUserRepository(pgDB), repository.NewAuthRepository(pgDB), cfg.OIDC)
+	oidcController := controller.NewOIDCController(oidcService, jwtService, sessions)
+	adminController := controller.NewAdminController(authService, sessions, studentService, videoService, personService, taxonomyService)
+
 	// 4. Router Setup
 	router := gin.New()
-	router.Use(gin.Recovery(), middlewares.Logger(), gindump.Dump())
+	if err := router.SetTrustedProxies(cfg.HTTPServer.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies:", err)
+	}
+	router.Use(gin.Recovery(), middlewares.Tracing(), middlewares.Logger(logger, cfg.Logging), middlewares.Metrics(), middlewares.Deadline(cfg.Timeouts))
+
+	templates, err := web.Templates()
+	if err != nil {
+		log.Fatal("Template parsing failed:", err)
+	}
+	router.SetHTMLTemplate(templates)
+
+	registerProbes(router, healthController)
 
 	// --- SWAGGER ROUTE ---
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Everything below is scoped to the tenant named by the host; the
+	// probes and docs above are not.
+	router.Use(middlewares.ResolveTenant(tenantService, cfg.Tenancy.BaseDomain))
+
 	// Public Routes
-	router.POST("/login", func(ctx *gin.Context) {
-		token := loginController.Login(ctx)
-		if token != "" {
-			ctx.JSON(http.StatusOK, gin.H{"token": token})
-			return
+	router.POST("/login", rateLimit("login"), loginController.Login)
+	router.POST("/login/mfa", rateLimit("login"), loginController.VerifyMFA)
+
+	// Single sign-on through the school's identity provider
+	if cfg.OIDC.Enabled {
+		router.GET("/auth/oidc/login", rateLimit("login"), oidcController.Login)
+		router.GET("/auth/oidc/callback", rateLimit("login"), oidcController.Callback)
+	}
+
+	// Self-registration and password reset by email
+	auth := router.Group("/auth", rateLimit("login"))
+	{
+		auth.POST("/register", accountController.Register)
+		auth.GET("/verify-email", accountController.VerifyEmail)
+		auth.POST("/verify-email", accountController.VerifyEmail)
+		auth.POST("/verify-email/resend", accountController.ResendVerification)
+		auth.POST("/password/forgot", accountController.ForgotPassword)
+		auth.GET("/reset-password", accountController.ResetPasswordPage)
+		auth.POST("/password/reset", accountController.ResetPassword)
+	}
+
+	// Admin UI (session cookie + CSRF)
+	admin := router.Group("/admin", rateLimit("public"), sessions.LoadSession(), middlewares.VerifyCSRF())
+	{
+		admin.GET("/login", adminController.LoginPage)
+		admin.POST("/login", rateLimit("login"), adminController.Login)
+		admin.POST("/logout", adminController.Logout)
+
+		pages := admin.Group("/", sessions.RequireAdmin("/admin/login", authService))
+		{
+			pages.GET("/", func(ctx *gin.Context) {
+				ctx.Redirect(http.StatusSeeOther, "/admin/students")
+			})
+
+			pages.GET("/students", adminController.ListStudents)
+			pages.GET("/students/new", adminController.NewStudent)
+			pages.POST("/students", adminController.CreateStudent)
+			pages.GET("/students/:id/edit", adminController.EditStudent)
+			pages.POST("/students/:id", adminController.UpdateStudent)
+			pages.POST("/students/:id/delete", adminController.DeleteStudent)
+
+			pages.GET("/videos", adminController.ListVideos)
+			pages.GET("/videos/new", adminController.NewVideo)
+			pages.POST("/videos", adminController.CreateVideo)
+			pages.GET("/videos/:id/edit", adminController.EditVideo)
+			pages.POST("/videos/:id", adminController.UpdateVideo)
+			pages.POST("/videos/:id/delete", adminController.DeleteVideo)
 		}
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-	})
+	}
+
+	// MFA enrollment stays reachable for users whose role requires MFA
+	// but who have not set it up yet.
+	mfa := router.Group("/api/auth/mfa", rateLimit("api_ip"), middlewares.AuthorizeJWT(jwtService, apiKeyService, tenantService), rateLimit("api"))
+	{
+		mfa.GET("", mfaController.Status)
+		mfa.POST("/enroll", mfaController.Enroll)
+		mfa.POST("/confirm", mfaController.Confirm)
+		mfa.POST("/recovery-codes", middlewares.RequireRecentMFA(cfg.MFA.RecentAuth), mfaController.RegenerateRecoveryCodes)
+		mfa.DELETE("", middlewares.RequireRecentMFA(cfg.MFA.RecentAuth), mfaController.Disable)
+	}
 
 	// Private Routes
-	api := router.Group("/api", middlewares.AuthorizeJWT(jwtService))
+	api := router.Group("/api", rateLimit("api_ip"), middlewares.AuthorizeJWT(jwtService, apiKeyService, tenantService), rateLimit("api"), middlewares.RequireMFA(cfg.MFA.RequiredRoles))
 	{
+		// Students see and edit their own record here, guardians see theirs
+		// and their students'
+		api.GET("/me", profileController.Me)
+		api.PATCH("/me", profileController.UpdateMe)
+
+		staff := middlewares.RequireStaff()
+
 		students := api.Group("/students")
 		{
 			// Make sure your handler functions have the correct annotations!
-			students.POST("/", studentController.Create)
-			students.GET("/:id", studentController.GetByID)
-			students.PUT("/:id", studentController.Update)
-			students.GET("/", studentController.GetList)
-			students.DELETE("/:id", studentController.Delete)
+			students.POST("/", staff, studentController.Create)
+			students.GET("/:id", middlewares.RequireOwnStudent(profileService, "id"), studentController.GetByID)
+			students.PUT("/:id", staff, studentController.Update)
+			students.GET("/", staff, studentController.GetList)
+			students.GET("/duplicates", staff, studentController.Duplicates)
+			students.DELETE("/:id", staff, studentController.Delete)
+			students.GET("/:id/transitions", staff, studentController.GetTransitions)
+			students.POST("/:id/transitions", staff, studentController.Transition)
+			students.POST("/:id/merge", middlewares.RequireAdmin(), middlewares.RequireRecentMFA(cfg.MFA.RecentAuth), studentController.Merge)
+			students.PUT("/:id/account", middlewares.RequireAdmin(), profileController.LinkAccount)
+			students.DELETE("/:id/account", middlewares.RequireAdmin(), profileController.UnlinkAccount)
+			students.GET("/:id/guardians", staff, guardianController.ForStudent)
+			students.PUT("/:id/guardians/:guardianId", staff, guardianController.Link)
+			students.DELETE("/:id/guardians/:guardianId", staff, guardianController.Unlink)
+		}
+
+		guardians := api.Group("/guardians", staff)
+		{
+			guardians.POST("/", guardianController.Create)
+			guardians.GET("/", guardianController.GetList)
+			guardians.GET("/:id", guardianController.GetByID)
+			guardians.PUT("/:id", guardianController.Update)
+			guardians.DELETE("/:id", guardianController.Delete)
+			guardians.GET("/:id/students", guardianController.GetStudents)
+			guardians.PUT("/:id/account", middlewares.RequireAdmin(), guardianController.LinkAccount)
+			guardians.DELETE("/:id/account", middlewares.RequireAdmin(), guardianController.UnlinkAccount)
 		}
 
 		videos := api.Group("/videos")
 		{
 			videos.GET("/", videoController.FindAll)
+			videos.GET("/search", videoController.Search)
+			videos.GET("/page", videoController.ShowAll)
+			videos.GET("/:id", videoController.GetByID)
 
 			// Note: These anonymous functions CANNOT be documented by Swagger.
 			// Move them to controller methods if you want them in the UI.
-			videos.POST("/", func(ctx *gin.Context) {
+			videos.POST("/", staff, func(ctx *gin.Context) {
 				_ = videoController.Save(ctx)
 			})
-			videos.PUT("/:id", func(ctx *gin.Context) {
+			videos.PUT("/by-url", staff, func(ctx *gin.Context) {
+				_ = videoController.Upsert(ctx)
+			})
+			videos.PUT("/:id", staff, func(ctx *gin.Context) {
 				_ = videoController.Update(ctx)
 			})
-			videos.DELETE("/:id", func(ctx *gin.Context) {
+			videos.DELETE("/:id", staff, func(ctx *gin.Context) {
 				_ = videoController.Delete(ctx)
 			})
+
+			videos.GET("/:id/rating", feedbackController.GetRatings)
+			videos.PUT("/:id/rating", feedbackController.Rate)
+			videos.GET("/:id/comments", feedbackController.GetComments)
+			videos.POST("/:id/comments", feedbackController.CreateComment)
 		}
-	}
 
-	// 5. Server Startup
-	srv := &http.Server{
-		Addr:    cfg.HTTPServer.Addr,
-		Handler: router,
-	}
+		// Feedback is given as the caller's own student record
+		comments := api.Group("/comments")
+		{
+			comments.PUT("/:id", feedbackController.EditComment)
+			comments.DELETE("/:id", feedbackController.DeleteComment)
+			comments.POST("/:id/report", feedbackController.ReportComment)
+		}
 
-	go func() {
-		// Log the actual Swagger URL for convenience
-		slog.Info("Swagger UI is available at http://localhost" + cfg.HTTPServer.Addr + "/swagger/index.html")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+		cacheAdmin := api.Group("/admin/cache", middlewares.RequireAdmin())
+		{
+			cacheAdmin.GET("/stats", cacheAdminController.Stats)
+			cacheAdmin.GET("/keys/*key", cacheAdminController.Inspect)
+			cacheAdmin.DELETE("/families/:family", cacheAdminController.Flush)
+			cacheAdmin.POST("/warm", cacheAdminController.Warm)
 		}
-	}()
+
+		authAdmin := api.Group("/admin/auth", middlewares.RequireAdmin(), middlewares.RequireRecentMFA(cfg.MFA.RecentAuth))
+		{
+			authAdmin.GET("/locks", authAdminController.Locks)
+			authAdmin.DELETE("/locks/users/:username", authAdminController.UnlockUser)
+			authAdmin.DELETE("/locks/ips/:ip", authAdminController.UnlockIP)
+			authAdmin.GET("/audit", authAdminController.Audit)
+		}
+
+		apiKeys := api.Group("/admin/api-keys", middlewares.RequireAdmin(), middlewares.RequireRecentMFA(cfg.MFA.RecentAuth))
+		{
+			apiKeys.POST("/", apiKeyController.Create)
+			apiKeys.GET("/", apiKeyController.List)
+			apiKeys.POST("/:id/rotate", apiKeyController.Rotate)
+			apiKeys.DELETE("/:id", apiKeyController.Revoke)
+		}
+
+		tenants := api.Group("/admin/tenants", middlewares.RequireAdmin(), middlewares.RequireRecentMFA(cfg.MFA.RecentAuth), middlewares.RequireOperator(cfg.Tenancy.DefaultTenant, cfg.Tenancy.Operators))
+		{
+			tenants.GET("/", tenantController.List)
+			tenants.POST("/", tenantController.Create)
+			tenants.PUT("/:id", tenantController.Update)
+		}
+
+		// Staff read the custom student attributes to fill them in; admins
+		// define them for their own tenant
+		api.GET("/admin/student-attributes", staff, tenantController.GetStudentAttributes)
+		api.PUT("/admin/student-attributes", middlewares.RequireAdmin(), middlewares.RequireRecentMFA(cfg.MFA.RecentAuth), tenantController.SetStudentAttributes)
+
+		moderation := api.Group("/moderation", middlewares.RequireAdmin())
+		{
+			moderation.GET("/comments", feedbackController.ModerationQueue)
+			moderation.POST("/comments/:id/approve", feedbackController.ApproveComment)
+			moderation.POST("/comments/:id/hide", feedbackController.HideComment)
+		}
+
+		api.GET("/tags/", taxonomyController.ListTags)
+
+		categories := api.Group("/categories")
+		{
+			categories.GET("/", taxonomyController.ListCategories)
+			categories.POST("/", staff, taxonomyController.CreateCategory)
+			categories.DELETE("/:id", staff, taxonomyController.DeleteCategory)
+		}
+
+		persons := api.Group("/persons", staff)
+		{
+			persons.POST("/", personController.Create)
+			persons.GET("/", personController.GetList)
+			persons.GET("/duplicates", personController.GetDuplicates)
+			persons.GET("/:id", personController.GetByID)
+			persons.PUT("/:id", personController.Update)
+			persons.DELETE("/:id", personController.Delete)
+			persons.GET("/:id/videos", personController.GetVideos)
+			persons.POST("/:id/merge", personController.Merge)
+		}
+	}
+
+	// Deliver queued emails in the background until shutdown
+	mailCtx, stopMail := context.WithCancel(context.Background())
+	defer stopMail()
+	go mailDispatcher.Run(mailCtx)
+
+	// 5. Hand the server over to the full router
+	handler.Store(router)
+	healthChecker.MarkStarted()
+	slog.Info("Startup complete, serving traffic")
 
 	// 6. Graceful Shutdown
 	quit := make(chan os.Signal, 1)
@@ -154,12 +548,28 @@ func main() {
 
 	slog.Info("Shutting down server...")
 
+	// Fail readiness first and give load balancers time to stop routing
+	// new requests here before connections are closed.
+	healthChecker.Drain()
+	time.Sleep(cfg.Health.DrainDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	context.AfterFunc(ctx, cancelRequests)
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			slog.Error("failed to stop the metrics server", "error", err)
+		}
+	}
+	stopMail()
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
 
 	slog.Info("Server exiting")
 }
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountController serves self-registration, email verification and
+// password reset. Endpoints that take an email address answer the same
+// whether or not it has an account.
+type AccountController interface {
+	Register(ctx *gin.Context)
+	VerifyEmail(ctx *gin.Context)
+	ResendVerification(ctx *gin.Context)
+	ForgotPassword(ctx *gin.Context)
+	ResetPasswordPage(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
+}
+
+type accountController struct {
+	service service.AccountService
+}
+
+// NewAccountController creates a new instance of the controller
+func NewAccountController(service service.AccountService) AccountController {
+	return &accountController{
+		service: service,
+	}
+}
+
+type registerRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required"`
+	Name     string `json:"name" binding:"max=255"`
+}
+
+type emailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type tokenRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" form:"token" binding:"required"`
+	Password string `json:"password" form:"password" binding:"required"`
+}
+
+// accepted is the answer to every request that may send an email.
+func accepted(ctx *gin.Context) {
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If the address can receive it, an email is on its way"})
+}
+
+func writeAccountError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidToken):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link"})
+	case errors.Is(err, service.ErrWeakPassword):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	default:
+		logging.FromContext(ctx.Request.Context()).Error("account request failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Request failed"})
+	}
+}
+
+// Register - POST /auth/register
+func (c *accountController) Register(ctx *gin.Context) {
+	var req registerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := c.service.Register(ctx.Request.Context(), service.Registration{
+		Email:    req.Email,
+		Password: req.Password,
+		Name:     req.Name,
+	})
+	if err != nil {
+		writeAccountError(ctx, err)
+		return
+	}
+	accepted(ctx)
+}
+
+// VerifyEmail - GET /auth/verify-email?token=... and POST /auth/verify-email
+// GET is what the link in the email opens.
+func (c *accountController) VerifyEmail(ctx *gin.Context) {
+	var req tokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.service.VerifyEmail(ctx.Request.Context(), req.Token); err != nil {
+		writeAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email address verified, you can now log in"})
+}
+
+// ResendVerification - POST /auth/verify-email/resend
+func (c *accountController) ResendVerification(ctx *gin.Context) {
+	var req emailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.service.ResendVerification(ctx.Request.Context(), req.Email); err != nil {
+		writeAccountError(ctx, err)
+		return
+	}
+	accepted(ctx)
+}
+
+// ForgotPassword - POST /auth/password/forgot
+func (c *accountController) ForgotPassword(ctx *gin.Context) {
+	var req emailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.service.RequestPasswordReset(ctx.Request.Context(), req.Email); err != nil {
+		writeAccountError(ctx, err)
+		return
+	}
+	accepted(ctx)
+}
+
+// ResetPasswordPage - GET /auth/reset-password?token=...
+// The link in the reset email opens this form.
+func (c *accountController) ResetPasswordPage(ctx *gin.Context) {
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.HTML(http.StatusOK, "reset_password.html", gin.H{
+		"title": "Reset password",
+		"token": ctx.Query("token"),
+	})
+}
+
+// ResetPassword - POST /auth/password/reset
+// Takes JSON from API clients and the form of ResetPasswordPage, which
+// gets the page back with the outcome.
+func (c *accountController) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	bindErr := ctx.ShouldBind(&req)
+	form := ctx.ContentType() != gin.MIMEJSON
+
+	err := bindErr
+	if err == nil {
+		err = c.service.ResetPassword(ctx.Request.Context(), req.Token, req.Password)
+	}
+	if form {
+		if bindErr != nil {
+			err = service.ErrWeakPassword
+			if req.Token == "" {
+				err = service.ErrInvalidToken
+			}
+		}
+		c.renderReset(ctx, req.Token, err)
+		return
+	}
+	switch {
+	case bindErr != nil:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+	case err != nil:
+		writeAccountError(ctx, err)
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"message": "Password changed, you can now log in"})
+	}
+}
+
+func (c *accountController) renderReset(ctx *gin.Context, token string, err error) {
+	ctx.Header("Referrer-Policy", "no-referrer")
+	data := gin.H{"title": "Reset password", "token": token}
+	status := http.StatusOK
+	switch {
+	case err == nil:
+		data["done"] = true
+	case errors.Is(err, service.ErrInvalidToken):
+		status = http.StatusBadRequest
+		data["flash"] = &middlewares.Flash{Kind: "error", Message: "This link is invalid or has expired, please request a new one"}
+	case errors.Is(err, service.ErrWeakPassword):
+		status = http.StatusBadRequest
+		data["flash"] = &middlewares.Flash{Kind: "error", Message: "The " + err.Error()}
+	default:
+		status = http.StatusInternalServerError
+		logging.FromContext(ctx.Request.Context()).Error("password reset failed", "error", err)
+		data["flash"] = &middlewares.Flash{Kind: "error", Message: "Something went wrong, please try again"}
+	}
+	ctx.HTML(status, "reset_password.html", data)
+}
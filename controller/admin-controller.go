@@ -0,0 +1,423 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// AdminController serves the server-rendered admin UI under /admin
+type AdminController interface {
+	LoginPage(ctx *gin.Context)
+	Login(ctx *gin.Context)
+	Logout(ctx *gin.Context)
+
+	ListStudents(ctx *gin.Context)
+	NewStudent(ctx *gin.Context)
+	CreateStudent(ctx *gin.Context)
+	EditStudent(ctx *gin.Context)
+	UpdateStudent(ctx *gin.Context)
+	DeleteStudent(ctx *gin.Context)
+
+	ListVideos(ctx *gin.Context)
+	NewVideo(ctx *gin.Context)
+	CreateVideo(ctx *gin.Context)
+	EditVideo(ctx *gin.Context)
+	UpdateVideo(ctx *gin.Context)
+	DeleteVideo(ctx *gin.Context)
+}
+
+type adminController struct {
+	authService    service.AuthService
+	sessions       *middlewares.SessionManager
+	studentService service.StudentService
+	videoService   service.VideoService
+	personService  service.PersonService
+	taxonomy       service.TaxonomyService
+	validate       *validator.Validate
+}
+
+// NewAdminController creates a new instance of the controller
+func NewAdminController(
+	authService service.AuthService,
+	sessions *middlewares.SessionManager,
+	studentService service.StudentService,
+	videoService service.VideoService,
+	personService service.PersonService,
+	taxonomy service.TaxonomyService,
+) AdminController {
+	return &adminController{
+		authService:    authService,
+		sessions:       sessions,
+		studentService: studentService,
+		videoService:   videoService,
+		personService:  personService,
+		taxonomy:       taxonomy,
+		validate:       validator.New(),
+	}
+}
+
+// render adds the session-derived values every page needs.
+func (c *adminController) render(ctx *gin.Context, status int, name string, data gin.H) {
+	data["user"] = middlewares.CurrentUser(ctx)
+	data["csrf"] = middlewares.CSRFToken(ctx)
+	data["flash"] = middlewares.PopFlash(ctx)
+	ctx.HTML(status, name, data)
+}
+
+func (c *adminController) redirect(ctx *gin.Context, location, kind, message string) {
+	if message != "" {
+		middlewares.SetFlash(ctx, kind, message)
+	}
+	ctx.Redirect(http.StatusSeeOther, location)
+}
+
+// LoginPage - GET /admin/login
+func (c *adminController) LoginPage(ctx *gin.Context) {
+	if middlewares.CurrentUser(ctx) != "" {
+		ctx.Redirect(http.StatusSeeOther, "/admin/students")
+		return
+	}
+	c.render(ctx, http.StatusOK, "login.html", gin.H{"title": "Login"})
+}
+
+// Login - POST /admin/login
+// Users with MFA enabled enter their code in the same form.
+func (c *adminController) Login(ctx *gin.Context) {
+	attempt := service.LoginAttempt{
+		Username:  ctx.PostForm("username"),
+		Password:  ctx.PostForm("password"),
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Channel:   "admin",
+	}
+	result, err := c.authService.Authenticate(ctx.Request.Context(), attempt)
+	if err == nil && result.MFAPending {
+		code := strings.TrimSpace(ctx.PostForm("code"))
+		if code == "" {
+			c.redirect(ctx, "/admin/login", "error", "Enter the code from your authenticator app")
+			return
+		}
+		attempt.Username = result.Username
+		result, err = c.authService.VerifyMFA(ctx.Request.Context(), attempt, code)
+	}
+	if err != nil {
+		var locked *service.LockedError
+		switch {
+		case errors.As(err, &locked):
+			c.redirect(ctx, "/admin/login", "error", "Too many failed login attempts, try again later")
+		case errors.Is(err, service.ErrInvalidMFACode):
+			c.redirect(ctx, "/admin/login", "error", "Invalid authentication code")
+		case errors.Is(err, service.ErrEmailNotVerified):
+			c.redirect(ctx, "/admin/login", "error", "Confirm your email address before logging in")
+		default:
+			c.redirect(ctx, "/admin/login", "error", "Invalid credentials")
+		}
+		return
+	}
+	if !result.Admin() {
+		c.redirect(ctx, "/admin/login", "error", "This account cannot use the admin UI")
+		return
+	}
+	if result.MFASetupRequired {
+		c.redirect(ctx, "/admin/login", "error", "This account must enable MFA before using the admin UI")
+		return
+	}
+	c.sessions.Login(ctx, result.Username)
+	c.redirect(ctx, "/admin/students", "success", "Welcome back, "+result.Username)
+}
+
+// Logout - POST /admin/logout
+func (c *adminController) Logout(ctx *gin.Context) {
+	c.sessions.Logout(ctx)
+	c.redirect(ctx, "/admin/login", "success", "You have been logged out")
+}
+
+func parseAdminID(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "Invalid ID format")
+		return 0, false
+	}
+	return id, true
+}
+
+// studentFromForm reads the student form fields into student and
+// validates it. Fields the form does not show, such as addresses, are
+// left as they are. Only the new student form has an enrollment status;
+// later changes go through the transitions API.
+func (c *adminController) studentFromForm(ctx *gin.Context, student *entity.Student) error {
+	dob, err := entity.ParseDate(strings.TrimSpace(ctx.PostForm("date_of_birth")))
+	if err != nil {
+		return fmt.Errorf("date of birth: %w", err)
+	}
+	student.StudentNumber = strings.TrimSpace(ctx.PostForm("student_number"))
+	student.Name = strings.TrimSpace(ctx.PostForm("name"))
+	student.Email = strings.TrimSpace(ctx.PostForm("email"))
+	student.DateOfBirth = &dob
+	if status, ok := ctx.GetPostForm("enrollment_status"); ok {
+		student.EnrollmentStatus = status
+	}
+	return c.validate.Struct(student)
+}
+
+// ListStudents - GET /admin/students
+func (c *adminController) ListStudents(ctx *gin.Context) {
+	students, err := c.studentService.FindAll(ctx.Request.Context())
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.render(ctx, http.StatusOK, "students.html", gin.H{
+		"title":    "Students",
+		"students": students,
+	})
+}
+
+// NewStudent - GET /admin/students/new
+func (c *adminController) NewStudent(ctx *gin.Context) {
+	c.render(ctx, http.StatusOK, "student_form.html", gin.H{
+		"title":    "New student",
+		"action":   "/admin/students",
+		"student":  entity.Student{EnrollmentStatus: entity.EnrollmentEnrolled},
+		"statuses": service.EnrollmentStatuses,
+	})
+}
+
+// CreateStudent - POST /admin/students
+func (c *adminController) CreateStudent(ctx *gin.Context) {
+	var student entity.Student
+	err := c.studentFromForm(ctx, &student)
+	if err == nil {
+		err = c.studentService.Create(ctx.Request.Context(), &student)
+	}
+	if err != nil {
+		c.redirect(ctx, "/admin/students/new", "error", "Could not create student: "+err.Error())
+		return
+	}
+	c.redirect(ctx, "/admin/students", "success", "Student created")
+}
+
+// EditStudent - GET /admin/students/:id/edit
+func (c *adminController) EditStudent(ctx *gin.Context) {
+	id, ok := parseAdminID(ctx)
+	if !ok {
+		return
+	}
+	student, err := c.studentService.FindByID(ctx.Request.Context(), uint(id))
+	if err != nil {
+		c.redirect(ctx, "/admin/students", "error", "Student not found")
+		return
+	}
+	c.render(ctx, http.StatusOK, "student_form.html", gin.H{
+		"title":   "Edit student",
+		"action":  fmt.Sprintf("/admin/students/%d", id),
+		"student": student,
+	})
+}
+
+// UpdateStudent - POST /admin/students/:id
+func (c *adminController) UpdateStudent(ctx *gin.Context) {
+	id, ok := parseAdminID(ctx)
+	if !ok {
+		return
+	}
+	editPath := fmt.Sprintf("/admin/students/%d/edit", id)
+
+	student, err := c.studentService.FindByID(ctx.Request.Context(), uint(id))
+	if err == nil {
+		err = c.studentFromForm(ctx, student)
+	}
+	if err == nil {
+		err = c.studentService.Update(ctx.Request.Context(), student)
+	}
+	if err != nil {
+		c.redirect(ctx, editPath, "error", "Could not update student: "+err.Error())
+		return
+	}
+	c.redirect(ctx, "/admin/students", "success", "Student updated")
+}
+
+// DeleteStudent - POST /admin/students/:id/delete
+func (c *adminController) DeleteStudent(ctx *gin.Context) {
+	id, ok := parseAdminID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.studentService.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		c.redirect(ctx, "/admin/students", "error", "Could not delete student: "+err.Error())
+		return
+	}
+	c.redirect(ctx, "/admin/students", "success", "Student deleted")
+}
+
+// videoFromForm reads the video form fields, applying the same rules as the
+// binding tags on entity.Video.
+func (c *adminController) videoFromForm(ctx *gin.Context) (entity.Video, error) {
+	authorID, err := strconv.ParseUint(ctx.PostForm("author_id"), 10, 64)
+	if err != nil {
+		return entity.Video{}, fmt.Errorf("an author must be selected")
+	}
+	video := entity.Video{
+		Title:       strings.TrimSpace(ctx.PostForm("title")),
+		Description: strings.TrimSpace(ctx.PostForm("description")),
+		URL:         strings.TrimSpace(ctx.PostForm("url")),
+		PersonID:    authorID,
+	}
+	for _, name := range strings.Split(ctx.PostForm("tags"), ",") {
+		video.Tags = append(video.Tags, entity.Tag{Name: name})
+	}
+	if raw := ctx.PostForm("category_id"); raw != "" {
+		categoryID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return video, fmt.Errorf("invalid category")
+		}
+		video.CategoryID = &categoryID
+	}
+	if len(video.Title) < 2 || len(video.Title) > 100 {
+		return video, fmt.Errorf("title must be between 2 and 100 characters")
+	}
+	if len(video.Description) > 200 {
+		return video, fmt.Errorf("description must be at most 200 characters")
+	}
+	if err := c.validate.Var(video.URL, "required,url"); err != nil {
+		return video, fmt.Errorf("url must be a valid URL")
+	}
+	return video, nil
+}
+
+// categoryOption is a category select entry, indented by tree depth.
+type categoryOption struct {
+	ID    uint64
+	Label string
+}
+
+func flattenCategories(nodes []entity.Category, depth int, out []categoryOption) []categoryOption {
+	for _, n := range nodes {
+		out = append(out, categoryOption{ID: n.ID, Label: strings.Repeat("— ", depth) + n.Name})
+		out = flattenCategories(n.Children, depth+1, out)
+	}
+	return out
+}
+
+func (c *adminController) renderVideoForm(ctx *gin.Context, title, action string, video entity.Video) {
+	persons, err := c.personService.FindAll(ctx.Request.Context())
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	tree, err := c.taxonomy.CategoryTree(ctx.Request.Context())
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	tagNames := make([]string, len(video.Tags))
+	for i, t := range video.Tags {
+		tagNames[i] = t.Name
+	}
+	var categoryID uint64
+	if video.CategoryID != nil {
+		categoryID = *video.CategoryID
+	}
+
+	c.render(ctx, http.StatusOK, "video_form.html", gin.H{
+		"title":      title,
+		"action":     action,
+		"video":      video,
+		"tags":       strings.Join(tagNames, ", "),
+		"categoryID": categoryID,
+		"categories": flattenCategories(tree, 0, nil),
+		"persons":    persons,
+	})
+}
+
+// ListVideos - GET /admin/videos
+func (c *adminController) ListVideos(ctx *gin.Context) {
+	videos, err := c.videoService.FindAll(ctx.Request.Context())
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.render(ctx, http.StatusOK, "videos.html", gin.H{
+		"title":  "Videos",
+		"videos": videos,
+	})
+}
+
+// NewVideo - GET /admin/videos/new
+func (c *adminController) NewVideo(ctx *gin.Context) {
+	c.renderVideoForm(ctx, "New video", "/admin/videos", entity.Video{})
+}
+
+// CreateVideo - POST /admin/videos
+func (c *adminController) CreateVideo(ctx *gin.Context) {
+	video, err := c.videoFromForm(ctx)
+	if err == nil {
+		_, err = c.videoService.Save(ctx.Request.Context(), video)
+	}
+	if err != nil {
+		c.redirect(ctx, "/admin/videos/new", "error", "Could not create video: "+err.Error())
+		return
+	}
+	c.redirect(ctx, "/admin/videos", "success", "Video created")
+}
+
+// EditVideo - GET /admin/videos/:id/edit
+func (c *adminController) EditVideo(ctx *gin.Context) {
+	id, ok := parseAdminID(ctx)
+	if !ok {
+		return
+	}
+	video, err := c.videoService.FindByID(ctx.Request.Context(), id)
+	if err != nil {
+		c.redirect(ctx, "/admin/videos", "error", "Video not found")
+		return
+	}
+	c.renderVideoForm(ctx, "Edit video", fmt.Sprintf("/admin/videos/%d", id), *video)
+}
+
+// UpdateVideo - POST /admin/videos/:id
+func (c *adminController) UpdateVideo(ctx *gin.Context) {
+	id, ok := parseAdminID(ctx)
+	if !ok {
+		return
+	}
+	editPath := fmt.Sprintf("/admin/videos/%d/edit", id)
+
+	existing, err := c.videoService.FindByID(ctx.Request.Context(), id)
+	if err != nil {
+		c.redirect(ctx, "/admin/videos", "error", "Video not found")
+		return
+	}
+
+	video, err := c.videoFromForm(ctx)
+	if err == nil {
+		video.ID = id
+		video.CreatedAt = existing.CreatedAt
+		err = c.videoService.Update(ctx.Request.Context(), video)
+	}
+	if err != nil {
+		c.redirect(ctx, editPath, "error", "Could not update video: "+err.Error())
+		return
+	}
+	c.redirect(ctx, "/admin/videos", "success", "Video updated")
+}
+
+// DeleteVideo - POST /admin/videos/:id/delete
+func (c *adminController) DeleteVideo(ctx *gin.Context) {
+	id, ok := parseAdminID(ctx)
+	if !ok {
+		return
+	}
+	c.videoService.Delete(ctx.Request.Context(), entity.Video{ID: id})
+	c.redirect(ctx, "/admin/videos", "success", "Video deleted")
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyController lets admins manage API keys for integrations
+type APIKeyController interface {
+	Create(ctx *gin.Context)
+	List(ctx *gin.Context)
+	Rotate(ctx *gin.Context)
+	Revoke(ctx *gin.Context)
+}
+
+type apiKeyController struct {
+	service service.APIKeyService
+}
+
+// NewAPIKeyController creates a new instance of the controller
+func NewAPIKeyController(service service.APIKeyService) APIKeyController {
+	return &apiKeyController{
+		service: service,
+	}
+}
+
+type rotateAPIKeyRequest struct {
+	// Grace is how long the old key keeps working, e.g. "1h".
+	Grace string `json:"grace"`
+}
+
+func writeAPIKeyError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+	case errors.Is(err, service.ErrInvalidScope):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAPIKeyRevoked):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func parseAPIKeyID(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// Create - POST /api/admin/api-keys
+// The key is only returned in this response.
+func (c *apiKeyController) Create(ctx *gin.Context) {
+	var req service.APIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	secret, key, err := c.service.Create(ctx.Request.Context(), req, middlewares.TokenUser(ctx))
+	if err != nil {
+		writeAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"key": secret, "api_key": key})
+}
+
+// List - GET /api/admin/api-keys
+func (c *apiKeyController) List(ctx *gin.Context) {
+	keys, err := c.service.List(ctx.Request.Context())
+	if err != nil {
+		writeAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// Rotate - POST /api/admin/api-keys/:id/rotate
+func (c *apiKeyController) Rotate(ctx *gin.Context) {
+	id, ok := parseAPIKeyID(ctx)
+	if !ok {
+		return
+	}
+	var req rotateAPIKeyRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var grace time.Duration
+	if req.Grace != "" {
+		d, err := time.ParseDuration(req.Grace)
+		if err != nil || d < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "grace must be a duration such as 1h"})
+			return
+		}
+		grace = d
+	}
+
+	secret, key, err := c.service.Rotate(ctx.Request.Context(), id, grace, middlewares.TokenUser(ctx))
+	if err != nil {
+		writeAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"key": secret, "api_key": key})
+}
+
+// Revoke - DELETE /api/admin/api-keys/:id
+func (c *apiKeyController) Revoke(ctx *gin.Context) {
+	id, ok := parseAPIKeyID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.service.Revoke(ctx.Request.Context(), id); err != nil {
+		writeAPIKeyError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
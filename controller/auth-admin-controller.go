@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthAdminController lets admins review login attempts and lift lockouts
+type AuthAdminController interface {
+	Locks(ctx *gin.Context)
+	UnlockUser(ctx *gin.Context)
+	UnlockIP(ctx *gin.Context)
+	Audit(ctx *gin.Context)
+}
+
+type authAdminController struct {
+	service service.AuthService
+}
+
+// NewAuthAdminController creates a new instance of the controller
+func NewAuthAdminController(service service.AuthService) AuthAdminController {
+	return &authAdminController{
+		service: service,
+	}
+}
+
+// Locks - GET /api/admin/auth/locks
+func (c *authAdminController) Locks(ctx *gin.Context) {
+	locks, err := c.service.Locks(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, locks)
+}
+
+func (c *authAdminController) unlock(ctx *gin.Context, kind, value string) {
+	if err := c.service.Unlock(ctx.Request.Context(), kind, value); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// UnlockUser - DELETE /api/admin/auth/locks/users/:username
+func (c *authAdminController) UnlockUser(ctx *gin.Context) {
+	c.unlock(ctx, service.LockUser, ctx.Param("username"))
+}
+
+// UnlockIP - DELETE /api/admin/auth/locks/ips/:ip
+func (c *authAdminController) UnlockIP(ctx *gin.Context) {
+	c.unlock(ctx, service.LockIP, ctx.Param("ip"))
+}
+
+// Audit - GET /api/admin/auth/audit
+// Accepts username, ip, success (true/false) and limit query parameters.
+func (c *authAdminController) Audit(ctx *gin.Context) {
+	filter := repository.LoginAuditFilter{
+		Username: ctx.Query("username"),
+		IP:       ctx.Query("ip"),
+	}
+	if v := ctx.Query("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "success must be true or false"})
+			return
+		}
+		filter.Success = &success
+	}
+	if v := ctx.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	audits, err := c.service.Audit(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, audits)
+}
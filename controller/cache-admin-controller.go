@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheAdminController exposes cache statistics and maintenance to admins
+type CacheAdminController interface {
+	Stats(ctx *gin.Context)
+	Inspect(ctx *gin.Context)
+	Flush(ctx *gin.Context)
+	Warm(ctx *gin.Context)
+}
+
+type cacheAdminController struct {
+	service service.CacheAdminService
+}
+
+// NewCacheAdminController creates a new instance of the controller
+func NewCacheAdminController(service service.CacheAdminService) CacheAdminController {
+	return &cacheAdminController{
+		service: service,
+	}
+}
+
+type warmRequest struct {
+	StudentIDs []uint `json:"student_ids" binding:"required,min=1,max=1000"`
+}
+
+// Stats - GET /api/admin/cache/stats
+func (c *cacheAdminController) Stats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.service.Stats())
+}
+
+// Inspect - GET /api/admin/cache/keys/*key
+func (c *cacheAdminController) Inspect(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	if key == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Key is required"})
+		return
+	}
+
+	entry, err := c.service.Inspect(ctx.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, cache.ErrMiss) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Key not cached"})
+			return
+		}
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, entry)
+}
+
+// Flush - DELETE /api/admin/cache/families/:family
+func (c *cacheAdminController) Flush(ctx *gin.Context) {
+	n, err := c.service.Flush(ctx.Request.Context(), ctx.Param("family"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownCacheFamily) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "deleted": n})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+// Warm - POST /api/admin/cache/warm
+func (c *cacheAdminController) Warm(ctx *gin.Context) {
+	var req warmRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, c.service.Warm(ctx.Request.Context(), req.StudentIDs))
+}
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/Sarthak-D97/go_stuAPI/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// FeedbackController handles video ratings, comments and their moderation
+type FeedbackController interface {
+	Rate(ctx *gin.Context)
+	GetRatings(ctx *gin.Context)
+	CreateComment(ctx *gin.Context)
+	GetComments(ctx *gin.Context)
+	EditComment(ctx *gin.Context)
+	DeleteComment(ctx *gin.Context)
+	ReportComment(ctx *gin.Context)
+	ModerationQueue(ctx *gin.Context)
+	ApproveComment(ctx *gin.Context)
+	HideComment(ctx *gin.Context)
+}
+
+type feedbackController struct {
+	service  service.FeedbackService
+	profiles service.ProfileService
+}
+
+// NewFeedbackController creates a new instance of the controller. Feedback
+// is given by the student record linked to the caller's account, which
+// profiles looks up.
+func NewFeedbackController(service service.FeedbackService, profiles service.ProfileService) FeedbackController {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("clean-text", validators.ValidateCleanText)
+	}
+	return &feedbackController{
+		service:  service,
+		profiles: profiles,
+	}
+}
+
+type rateRequest struct {
+	Score int `json:"score" binding:"required,gte=1,lte=5"`
+}
+
+type commentRequest struct {
+	ParentID *uint64 `json:"parent_id"`
+	Body     string  `json:"body" binding:"required,max=2000,clean-text"`
+}
+
+type editCommentRequest struct {
+	Body string `json:"body" binding:"required,max=2000,clean-text"`
+}
+
+type reportRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// callerStudentID returns the ID of the student record linked to the
+// caller's account. Accounts without one get 403.
+func (c *feedbackController) callerStudentID(ctx *gin.Context) (uint64, bool) {
+	id, err := c.profiles.StudentID(ctx.Request.Context(), middlewares.TokenUser(ctx))
+	if errors.Is(err, service.ErrNoStudentRecord) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only accounts linked to a student record can give feedback"})
+		return 0, false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return uint64(id), true
+}
+
+func parseFeedbackID(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeFeedbackError maps a feedback service error onto an HTTP response.
+func writeFeedbackError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Video, student or comment not found"})
+	case errors.Is(err, service.ErrNotCommentAuthor):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEditWindowClosed),
+		errors.Is(err, service.ErrDeleteWindowClosed),
+		errors.Is(err, service.ErrCommentDeleted),
+		errors.Is(err, service.ErrAlreadyReported):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	case errors.Is(err, service.ErrInvalidParent):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Rate - PUT /api/videos/:id/rating
+func (c *feedbackController) Rate(ctx *gin.Context) {
+	videoID, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	var req rateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	studentID, ok := c.callerStudentID(ctx)
+	if !ok {
+		return
+	}
+	rating := entity.Rating{VideoID: videoID, StudentID: studentID, Score: req.Score}
+
+	summary, err := c.service.Rate(ctx.Request.Context(), rating)
+	if err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// GetRatings - GET /api/videos/:id/rating
+func (c *feedbackController) GetRatings(ctx *gin.Context) {
+	videoID, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	summary, err := c.service.Ratings(ctx.Request.Context(), videoID)
+	if err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// CreateComment - POST /api/videos/:id/comments
+func (c *feedbackController) CreateComment(ctx *gin.Context) {
+	videoID, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	var req commentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	studentID, ok := c.callerStudentID(ctx)
+	if !ok {
+		return
+	}
+	comment := entity.Comment{VideoID: videoID, StudentID: studentID, ParentID: req.ParentID, Body: req.Body}
+
+	if err := c.service.Comment(ctx.Request.Context(), &comment); err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, comment)
+}
+
+// GetComments - GET /api/videos/:id/comments
+func (c *feedbackController) GetComments(ctx *gin.Context) {
+	videoID, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	thread, err := c.service.Thread(ctx.Request.Context(), videoID)
+	if err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, thread)
+}
+
+// EditComment - PUT /api/comments/:id
+func (c *feedbackController) EditComment(ctx *gin.Context) {
+	id, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	var req editCommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	studentID, ok := c.callerStudentID(ctx)
+	if !ok {
+		return
+	}
+
+	comment, err := c.service.EditComment(ctx.Request.Context(), id, studentID, req.Body)
+	if err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment - DELETE /api/comments/:id
+func (c *feedbackController) DeleteComment(ctx *gin.Context) {
+	id, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	studentID, ok := c.callerStudentID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteComment(ctx.Request.Context(), id, studentID); err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// ReportComment - POST /api/comments/:id/report
+func (c *feedbackController) ReportComment(ctx *gin.Context) {
+	id, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+
+	var req reportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	studentID, ok := c.callerStudentID(ctx)
+	if !ok {
+		return
+	}
+	report := entity.CommentReport{CommentID: id, StudentID: studentID, Reason: req.Reason}
+
+	if err := c.service.Report(ctx.Request.Context(), report); err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Comment reported"})
+}
+
+// ModerationQueue - GET /api/moderation/comments
+func (c *feedbackController) ModerationQueue(ctx *gin.Context) {
+	comments, err := c.service.ModerationQueue(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, comments)
+}
+
+// ApproveComment - POST /api/moderation/comments/:id/approve
+func (c *feedbackController) ApproveComment(ctx *gin.Context) {
+	id, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.service.Approve(ctx.Request.Context(), id); err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment approved"})
+}
+
+// HideComment - POST /api/moderation/comments/:id/hide
+func (c *feedbackController) HideComment(ctx *gin.Context) {
+	id, ok := parseFeedbackID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.service.Hide(ctx.Request.Context(), id); err != nil {
+		writeFeedbackError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment hidden"})
+}
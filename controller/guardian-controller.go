@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GuardianController manages guardians, their links to students and their
+// portal accounts
+type GuardianController interface {
+	Create(ctx *gin.Context)
+	GetByID(ctx *gin.Context)
+	GetList(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	GetStudents(ctx *gin.Context)
+	ForStudent(ctx *gin.Context)
+	Link(ctx *gin.Context)
+	Unlink(ctx *gin.Context)
+	LinkAccount(ctx *gin.Context)
+	UnlinkAccount(ctx *gin.Context)
+}
+
+type guardianController struct {
+	service service.GuardianService
+}
+
+// NewGuardianController creates a new instance of the controller
+func NewGuardianController(service service.GuardianService) GuardianController {
+	return &guardianController{
+		service: service,
+	}
+}
+
+// guardianLinkRequest holds the details of a student's link to a guardian.
+type guardianLinkRequest struct {
+	Relationship     string `json:"relationship" binding:"required,oneof=mother father parent stepparent grandparent sibling foster_parent legal_guardian other"`
+	Priority         int    `json:"priority" binding:"gte=0"`
+	PickupAuthorized bool   `json:"pickup_authorized"`
+	EmergencyContact bool   `json:"emergency_contact"`
+}
+
+func parseGuardianID(ctx *gin.Context, param string) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param(param), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeGuardianError maps a guardian service error onto an HTTP response.
+func writeGuardianError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Guardian, student or link not found"})
+	case errors.Is(err, service.ErrGuardianContact), errors.Is(err, service.ErrGuardianPhone),
+		errors.Is(err, service.ErrStaffNotLinkable):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrGuardianLinked), errors.Is(err, service.ErrAccountLinked):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Create - POST /api/guardians/
+func (c *guardianController) Create(ctx *gin.Context) {
+	var guardian entity.Guardian
+	if err := ctx.ShouldBindJSON(&guardian); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	guardian.ID = 0
+
+	if err := c.service.Create(ctx.Request.Context(), &guardian); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, guardian)
+}
+
+// GetByID - GET /api/guardians/:id
+func (c *guardianController) GetByID(ctx *gin.Context) {
+	id, ok := parseGuardianID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	guardian, err := c.service.FindByID(ctx.Request.Context(), id)
+	if err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, guardian)
+}
+
+// GetList - GET /api/guardians/
+func (c *guardianController) GetList(ctx *gin.Context) {
+	guardians, err := c.service.FindAll(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guardians)
+}
+
+// Update - PUT /api/guardians/:id
+func (c *guardianController) Update(ctx *gin.Context) {
+	id, ok := parseGuardianID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var guardian entity.Guardian
+	if err := ctx.ShouldBindJSON(&guardian); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	guardian.ID = id
+
+	if err := c.service.Update(ctx.Request.Context(), &guardian); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, guardian)
+}
+
+// Delete - DELETE /api/guardians/:id
+// Also removes the guardian's student links and detaches its account.
+func (c *guardianController) Delete(ctx *gin.Context) {
+	id, ok := parseGuardianID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Guardian deleted successfully"})
+}
+
+// GetStudents - GET /api/guardians/:id/students
+func (c *guardianController) GetStudents(ctx *gin.Context) {
+	id, ok := parseGuardianID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	students, err := c.service.Students(ctx.Request.Context(), id)
+	if err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, students)
+}
+
+// ForStudent - GET /api/students/:id/guardians
+// Lists the student's guardians in contact order.
+func (c *guardianController) ForStudent(ctx *gin.Context) {
+	studentID, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+
+	links, err := c.service.ForStudent(ctx.Request.Context(), studentID)
+	if err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, links)
+}
+
+// Link - PUT /api/students/:id/guardians/:guardianId
+// Links the guardian to the student or replaces the link's details.
+func (c *guardianController) Link(ctx *gin.Context) {
+	studentID, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+	guardianID, ok := parseGuardianID(ctx, "guardianId")
+	if !ok {
+		return
+	}
+	var req guardianLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link := entity.StudentGuardian{
+		StudentID:        studentID,
+		GuardianID:       guardianID,
+		Relationship:     req.Relationship,
+		Priority:         req.Priority,
+		PickupAuthorized: req.PickupAuthorized,
+		EmergencyContact: req.EmergencyContact,
+	}
+	if err := c.service.Link(ctx.Request.Context(), &link); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, link)
+}
+
+// Unlink - DELETE /api/students/:id/guardians/:guardianId
+func (c *guardianController) Unlink(ctx *gin.Context) {
+	studentID, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+	guardianID, ok := parseGuardianID(ctx, "guardianId")
+	if !ok {
+		return
+	}
+
+	if err := c.service.Unlink(ctx.Request.Context(), studentID, guardianID); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Guardian unlinked"})
+}
+
+// LinkAccount - PUT /api/guardians/:id/account
+// Makes the registered account with that username the guardian's portal
+// account.
+func (c *guardianController) LinkAccount(ctx *gin.Context) {
+	id, ok := parseGuardianID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req linkAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.LinkAccount(ctx.Request.Context(), id, req.Username); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account linked"})
+}
+
+// UnlinkAccount - DELETE /api/guardians/:id/account
+func (c *guardianController) UnlinkAccount(ctx *gin.Context) {
+	id, ok := parseGuardianID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.UnlinkAccount(ctx.Request.Context(), id); err != nil {
+		writeGuardianError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account unlinked"})
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/health"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController interface {
+	Live(ctx *gin.Context)
+	Ready(ctx *gin.Context)
+	Startup(ctx *gin.Context)
+}
+
+type healthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) HealthController {
+	return &healthController{checker: checker}
+}
+
+// Live - GET /healthz
+// The process is up and serving HTTP; dependencies are not checked.
+func (c *healthController) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Ready - GET /readyz
+// Checks Postgres, Redis and the video store. Answers 503 while starting,
+// when a critical dependency fails, or once shutdown has begun.
+func (c *healthController) Ready(ctx *gin.Context) {
+	report, ready := c.checker.Ready(ctx.Request.Context())
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// Startup - GET /startupz
+// Answers 503 until connections are open and migrations have run.
+func (c *healthController) Startup(ctx *gin.Context) {
+	if !c.checker.Started() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusFail, "reason": "starting"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
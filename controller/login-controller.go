@@ -1,19 +1,26 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
 	"github.com/Sarthak-D97/go_stuAPI/service"
 	"github.com/gin-gonic/gin"
 )
 
 type LoginController struct {
-	loginService service.LoginService
-	jwtService   service.JWTService
+	authService service.AuthService
+	jwtService  service.JWTService
 }
 
-func NewLoginController(loginService service.LoginService, jwtService service.JWTService) *LoginController {
+func NewLoginController(authService service.AuthService, jwtService service.JWTService) *LoginController {
 	return &LoginController{
-		loginService: loginService,
-		jwtService:   jwtService,
+		authService: authService,
+		jwtService:  jwtService,
 	}
 }
 
@@ -22,15 +29,105 @@ type LoginCredentials struct {
 	Password string `json:"password"`
 }
 
-func (controller *LoginController) Login(ctx *gin.Context) string {
+type MFACredentials struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func (controller *LoginController) attempt(ctx *gin.Context, username, password string) service.LoginAttempt {
+	return service.LoginAttempt{
+		Username:  username,
+		Password:  password,
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Channel:   "api",
+	}
+}
+
+// requestTenant returns the slug of the tenant the request was resolved to.
+func requestTenant(ctx *gin.Context) string {
+	if t := service.TenantFrom(ctx.Request.Context()); t != nil {
+		return t.Slug
+	}
+	return ""
+}
+
+// issueToken answers a finished login with an access token for the
+// request's tenant.
+func issueToken(ctx *gin.Context, jwtService service.JWTService, result service.LoginResult) {
+	body := gin.H{"token": jwtService.GenerateToken(result.Username, result.Role, requestTenant(ctx), result.AMR...)}
+	if result.MFASetupRequired {
+		body["mfa_enrollment_required"] = true
+	}
+	ctx.JSON(http.StatusOK, body)
+}
+
+// Login - POST /login
+// Wrong usernames and wrong passwords get the same 401; a locked out
+// username or client IP gets 429 with Retry-After, a registered user who
+// has not confirmed their email address 403. Users with MFA enabled
+// get an mfa_token to finish the login at POST /login/mfa.
+func (controller *LoginController) Login(ctx *gin.Context) {
 	var credentials LoginCredentials
 	if err := ctx.ShouldBindJSON(&credentials); err != nil {
-		return ""
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
 	}
 
-	isAuthenticated := controller.loginService.Login(credentials.Username, credentials.Password)
-	if isAuthenticated {
-		return controller.jwtService.GenerateToken(credentials.Username, true)
+	result, err := controller.authService.Authenticate(ctx.Request.Context(), controller.attempt(ctx, credentials.Username, credentials.Password))
+	if err != nil {
+		writeLoginError(ctx, err)
+		return
+	}
+	if result.MFAPending {
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    controller.jwtService.GenerateMFAChallenge(result.Username, requestTenant(ctx)),
+		})
+		return
+	}
+	issueToken(ctx, controller.jwtService, result)
+}
+
+// VerifyMFA - POST /login/mfa
+func (controller *LoginController) VerifyMFA(ctx *gin.Context) {
+	var credentials MFACredentials
+	if err := ctx.ShouldBindJSON(&credentials); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The challenge only finishes a login in the tenant that checked the
+	// password.
+	username, tenant, err := controller.jwtService.ValidateMFAChallenge(credentials.MFAToken)
+	if err != nil || tenant != requestTenant(ctx) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	result, err := controller.authService.VerifyMFA(ctx.Request.Context(), controller.attempt(ctx, username, ""), credentials.Code)
+	if err != nil {
+		writeLoginError(ctx, err)
+		return
+	}
+	issueToken(ctx, controller.jwtService, result)
+}
+
+func writeLoginError(ctx *gin.Context, err error) {
+	var locked *service.LockedError
+	switch {
+	case errors.As(err, &locked):
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+	case errors.Is(err, service.ErrInvalidCredentials):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+	case errors.Is(err, service.ErrEmailNotVerified):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Confirm your email address before logging in"})
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "Login timed out"})
+	default:
+		logging.FromContext(ctx.Request.Context()).Error("login failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
 	}
-	return ""
 }
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAController lets the logged in user manage their TOTP second factor
+type MFAController interface {
+	Status(ctx *gin.Context)
+	Enroll(ctx *gin.Context)
+	Confirm(ctx *gin.Context)
+	RegenerateRecoveryCodes(ctx *gin.Context)
+	Disable(ctx *gin.Context)
+}
+
+type mfaController struct {
+	service service.MFAService
+}
+
+// NewMFAController creates a new instance of the controller
+func NewMFAController(service service.MFAService) MFAController {
+	return &mfaController{
+		service: service,
+	}
+}
+
+type confirmMFARequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func writeMFAError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Status - GET /api/auth/mfa
+func (c *mfaController) Status(ctx *gin.Context) {
+	status, err := c.service.Status(ctx.Request.Context(), middlewares.TokenUser(ctx))
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
+
+// Enroll - POST /api/auth/mfa/enroll
+// Returns the secret and an otpauth:// URI to show as a QR code.
+func (c *mfaController) Enroll(ctx *gin.Context) {
+	setup, err := c.service.Enroll(ctx.Request.Context(), middlewares.TokenUser(ctx))
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, setup)
+}
+
+// Confirm - POST /api/auth/mfa/confirm
+func (c *mfaController) Confirm(ctx *gin.Context) {
+	var req confirmMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := c.service.Confirm(ctx.Request.Context(), middlewares.TokenUser(ctx), req.Code)
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// RegenerateRecoveryCodes - POST /api/auth/mfa/recovery-codes
+func (c *mfaController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	codes, err := c.service.RegenerateRecoveryCodes(ctx.Request.Context(), middlewares.TokenUser(ctx))
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Disable - DELETE /api/auth/mfa
+func (c *mfaController) Disable(ctx *gin.Context) {
+	if err := c.service.Disable(ctx.Request.Context(), middlewares.TokenUser(ctx)); err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcStateCookie = "stuapi_oidc"
+	// oidcStateTTL is how long the user may take at the identity provider.
+	oidcStateTTL = 10 * time.Minute
+)
+
+// OIDCController handles single sign-on through the identity provider
+type OIDCController interface {
+	Login(ctx *gin.Context)
+	Callback(ctx *gin.Context)
+}
+
+type oidcController struct {
+	service    service.OIDCService
+	jwtService service.JWTService
+	sessions   *middlewares.SessionManager
+}
+
+// NewOIDCController creates a new instance of the controller
+func NewOIDCController(service service.OIDCService, jwtService service.JWTService, sessions *middlewares.SessionManager) OIDCController {
+	return &oidcController{
+		service:    service,
+		jwtService: jwtService,
+		sessions:   sessions,
+	}
+}
+
+// Login - GET /auth/oidc/login
+// Redirects the browser to the identity provider.
+func (c *oidcController) Login(ctx *gin.Context) {
+	redirect, state, err := c.service.Begin(ctx.Request.Context())
+	if err != nil {
+		logging.FromContext(ctx.Request.Context()).Error("oidc login could not start", "error", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+		return
+	}
+	if err := c.sessions.SetSignedCookie(ctx, oidcStateCookie, state, oidcStateTTL); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Redirect(http.StatusFound, redirect)
+}
+
+// Callback - GET /auth/oidc/callback
+// Answers with our own JWT, like POST /login.
+func (c *oidcController) Callback(ctx *gin.Context) {
+	if idpErr := ctx.Query("error"); idpErr != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider refused the login: " + idpErr})
+		return
+	}
+
+	var state service.OIDCState
+	if !c.sessions.PopSignedCookie(ctx, oidcStateCookie, &state) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Login session expired, start again at /auth/oidc/login"})
+		return
+	}
+
+	result, err := c.service.Complete(ctx.Request.Context(), state, ctx.Query("state"), ctx.Query("code"), service.LoginAttempt{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Channel:   "oidc",
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCState):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Login session mismatch, start again at /auth/oidc/login"})
+		case errors.Is(err, service.ErrOIDCNoRole):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Your account is not allowed to sign in here"})
+		case errors.Is(err, service.ErrOIDCUsernameTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logging.FromContext(ctx.Request.Context()).Error("oidc login failed", "error", err)
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Single sign-on failed"})
+		}
+		return
+	}
+	issueToken(ctx, c.jwtService, result)
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PersonController exposes video authors as a resource of their own
+type PersonController interface {
+	Create(ctx *gin.Context)
+	GetByID(ctx *gin.Context)
+	GetList(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	GetVideos(ctx *gin.Context)
+	GetDuplicates(ctx *gin.Context)
+	Merge(ctx *gin.Context)
+}
+
+type personController struct {
+	service service.PersonService
+}
+
+// NewPersonController creates a new instance of the controller
+func NewPersonController(service service.PersonService) PersonController {
+	return &personController{
+		service: service,
+	}
+}
+
+type mergeRequest struct {
+	SourceID uint64 `json:"source_id" binding:"required"`
+}
+
+func parsePersonID(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writePersonError maps a person service error onto an HTTP response.
+func writePersonError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+	case errors.Is(err, service.ErrDuplicateEmail), errors.Is(err, service.ErrPersonHasVideos):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	case errors.Is(err, service.ErrSelfMerge):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Create - POST /api/persons/
+func (c *personController) Create(ctx *gin.Context) {
+	var person entity.Person
+	if err := ctx.ShouldBindJSON(&person); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.Create(ctx.Request.Context(), &person); err != nil {
+		if errors.Is(err, service.ErrDuplicateEmail) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "existing": person})
+			return
+		}
+		writePersonError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, person)
+}
+
+// GetByID - GET /api/persons/:id
+func (c *personController) GetByID(ctx *gin.Context) {
+	id, ok := parsePersonID(ctx)
+	if !ok {
+		return
+	}
+
+	person, err := c.service.FindByID(ctx.Request.Context(), id)
+	if err != nil {
+		writePersonError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+// GetList - GET /api/persons/
+func (c *personController) GetList(ctx *gin.Context) {
+	persons, err := c.service.FindAll(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, persons)
+}
+
+// Update - PUT /api/persons/:id
+func (c *personController) Update(ctx *gin.Context) {
+	id, ok := parsePersonID(ctx)
+	if !ok {
+		return
+	}
+
+	var person entity.Person
+	if err := ctx.ShouldBindJSON(&person); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	person.ID = id
+
+	if err := c.service.Update(ctx.Request.Context(), &person); err != nil {
+		writePersonError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+// Delete - DELETE /api/persons/:id
+func (c *personController) Delete(ctx *gin.Context) {
+	id, ok := parsePersonID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
+		writePersonError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Person deleted successfully"})
+}
+
+// GetVideos - GET /api/persons/:id/videos
+func (c *personController) GetVideos(ctx *gin.Context) {
+	id, ok := parsePersonID(ctx)
+	if !ok {
+		return
+	}
+
+	videos, err := c.service.Videos(ctx.Request.Context(), id)
+	if err != nil {
+		writePersonError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, videos)
+}
+
+// GetDuplicates - GET /api/persons/duplicates
+func (c *personController) GetDuplicates(ctx *gin.Context) {
+	groups, err := c.service.Duplicates(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, groups)
+}
+
+// Merge - POST /api/persons/:id/merge
+// Repoints the videos of source_id to :id and deletes source_id.
+func (c *personController) Merge(ctx *gin.Context) {
+	id, ok := parsePersonID(ctx)
+	if !ok {
+		return
+	}
+
+	var req mergeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	person, err := c.service.Merge(ctx.Request.Context(), id, req.SourceID)
+	if err != nil {
+		writePersonError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, person)
+}
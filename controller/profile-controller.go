@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// ProfileController serves the caller's own account and lets staff link
+// student accounts to student records
+type ProfileController interface {
+	Me(ctx *gin.Context)
+	UpdateMe(ctx *gin.Context)
+	LinkAccount(ctx *gin.Context)
+	UnlinkAccount(ctx *gin.Context)
+}
+
+type profileController struct {
+	service service.ProfileService
+}
+
+// NewProfileController creates a new instance of the controller
+func NewProfileController(service service.ProfileService) ProfileController {
+	return &profileController{
+		service: service,
+	}
+}
+
+type linkAccountRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+func writeProfileError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNoStudentRecord):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Student or account not found"})
+	case errors.Is(err, service.ErrStudentLinked), errors.Is(err, service.ErrAccountLinked):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStaffNotLinkable):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Me - GET /api/me
+func (c *profileController) Me(ctx *gin.Context) {
+	profile, err := c.service.Me(ctx.Request.Context(), middlewares.TokenUser(ctx), middlewares.TokenRole(ctx))
+	if err != nil {
+		writeProfileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, profile)
+}
+
+// UpdateMe - PATCH /api/me
+// Students may only change their contact details; any other field is
+// rejected rather than silently ignored.
+func (c *profileController) UpdateMe(ctx *gin.Context) {
+	dec := json.NewDecoder(ctx.Request.Body)
+	dec.DisallowUnknownFields()
+	var update service.ProfileUpdate
+	if err := dec.Decode(&update); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only contact details can be changed: " + err.Error()})
+		return
+	}
+	if err := binding.Validator.ValidateStruct(update); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	student, err := c.service.UpdateMe(ctx.Request.Context(), middlewares.TokenUser(ctx), update)
+	if err != nil {
+		writeProfileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, student)
+}
+
+func parseStudentID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// LinkAccount - PUT /api/students/:id/account
+// Makes the registered account with that username the student's own.
+func (c *profileController) LinkAccount(ctx *gin.Context) {
+	id, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+	var req linkAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.Link(ctx.Request.Context(), id, req.Username); err != nil {
+		writeProfileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account linked"})
+}
+
+// UnlinkAccount - DELETE /api/students/:id/account
+func (c *profileController) UnlinkAccount(ctx *gin.Context) {
+	id, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.Unlink(ctx.Request.Context(), id); err != nil {
+		writeProfileError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account unlinked"})
+}
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
 	"github.com/Sarthak-D97/go_stuAPI/service"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 )
 
 // StudentController defines the interface for the controller
@@ -16,16 +22,41 @@ type StudentController interface {
 	GetList(ctx *gin.Context)
 	Update(ctx *gin.Context)
 	Delete(ctx *gin.Context)
+	Transition(ctx *gin.Context)
+	GetTransitions(ctx *gin.Context)
+	Duplicates(ctx *gin.Context)
+	Merge(ctx *gin.Context)
 }
 
 type studentController struct {
-	service service.StudentService
+	service  service.StudentService
+	validate *validator.Validate
 }
 
 // NewStudentController creates a new instance of the controller
 func NewStudentController(service service.StudentService) StudentController {
 	return &studentController{
-		service: service,
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// writeStudentError maps a student service error onto an HTTP response.
+func writeStudentError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+	case errors.Is(err, service.ErrInvalidStudent):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnknownStatus):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransitionNotAllowed), errors.Is(err, service.ErrStatusChanged),
+		errors.Is(err, service.ErrMergeAccounts):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStudentNumberTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
 
@@ -38,11 +69,15 @@ func (c *studentController) Create(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := c.validate.Struct(student); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Call service
-	err := c.service.Create(&student)
+	err := c.service.Create(ctx.Request.Context(), &student)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStudentError(ctx, err)
 		return
 	}
 
@@ -59,8 +94,14 @@ func (c *studentController) GetByID(ctx *gin.Context) {
 	}
 
 	// Casting to uint because your Service expects uint (based on previous steps)
-	student, err := c.service.FindByID(uint(id))
+	student, err := c.service.FindByID(ctx.Request.Context(), uint(id))
 	if err != nil {
+		// A merged student's ID keeps pointing at the surviving record.
+		if into, mergeErr := c.service.MergedInto(ctx.Request.Context(), int(id)); mergeErr == nil {
+			ctx.Header("Location", fmt.Sprintf("/api/students/%d", into))
+			ctx.JSON(http.StatusMovedPermanently, gin.H{"error": "Student was merged into another record", "merged_into": into})
+			return
+		}
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
 		return
 	}
@@ -69,8 +110,28 @@ func (c *studentController) GetByID(ctx *gin.Context) {
 }
 
 // GetList - GET /api/students/
+// ?status=enrolled,suspended (or a repeated status parameter) lists only
+// the students in those enrollment statuses.
 func (c *studentController) GetList(ctx *gin.Context) {
-	students, err := c.service.FindAll()
+	var statuses []string
+	for _, param := range ctx.QueryArray("status") {
+		for _, status := range strings.Split(param, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	if len(statuses) > 0 {
+		students, err := c.service.FindByStatus(ctx.Request.Context(), statuses)
+		if err != nil {
+			writeStudentError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, students)
+		return
+	}
+
+	students, err := c.service.FindAll(ctx.Request.Context())
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -92,13 +153,17 @@ func (c *studentController) Update(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := c.validate.Struct(student); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// FIX: Cast to int because entity.Student.ID is an int
 	student.ID = int(id)
 
-	err = c.service.Update(&student)
+	err = c.service.Update(ctx.Request.Context(), &student)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStudentError(ctx, err)
 		return
 	}
 
@@ -115,7 +180,7 @@ func (c *studentController) Delete(ctx *gin.Context) {
 	}
 
 	// Casting to uint for the Service call
-	err = c.service.Delete(uint(id))
+	err = c.service.Delete(ctx.Request.Context(), uint(id))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -123,3 +188,72 @@ func (c *studentController) Delete(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Student deleted successfully"})
 }
+
+// Transition - POST /api/students/:id/transitions
+// Moves the student to another enrollment status and records the reason
+// and the acting user.
+func (c *studentController) Transition(ctx *gin.Context) {
+	id, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+	var req service.TransitionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transition, err := c.service.Transition(ctx.Request.Context(), id, req, middlewares.TokenUser(ctx))
+	if err != nil {
+		writeStudentError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, transition)
+}
+
+// GetTransitions - GET /api/students/:id/transitions
+func (c *studentController) GetTransitions(ctx *gin.Context) {
+	id, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+
+	transitions, err := c.service.Transitions(ctx.Request.Context(), id)
+	if err != nil {
+		writeStudentError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, transitions)
+}
+
+// Duplicates - GET /api/students/duplicates
+// Reports pairs of students that are probably the same person.
+func (c *studentController) Duplicates(ctx *gin.Context) {
+	candidates, err := c.service.Duplicates(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, candidates)
+}
+
+// Merge - POST /api/students/:id/merge
+// Merges the student duplicate_id into this one.
+func (c *studentController) Merge(ctx *gin.Context) {
+	id, ok := parseStudentID(ctx)
+	if !ok {
+		return
+	}
+	var req service.MergeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	student, err := c.service.Merge(ctx.Request.Context(), id, req, middlewares.TokenUser(ctx))
+	if err != nil {
+		writeStudentError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, student)
+}
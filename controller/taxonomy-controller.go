@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TaxonomyController manages video tags and the category tree
+type TaxonomyController interface {
+	ListTags(ctx *gin.Context)
+	ListCategories(ctx *gin.Context)
+	CreateCategory(ctx *gin.Context)
+	DeleteCategory(ctx *gin.Context)
+}
+
+type taxonomyController struct {
+	service service.TaxonomyService
+}
+
+// NewTaxonomyController creates a new instance of the controller
+func NewTaxonomyController(service service.TaxonomyService) TaxonomyController {
+	return &taxonomyController{
+		service: service,
+	}
+}
+
+// ListTags - GET /api/tags/
+func (c *taxonomyController) ListTags(ctx *gin.Context) {
+	tags, err := c.service.ListTags(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, tags)
+}
+
+// ListCategories - GET /api/categories/
+// Returns the root categories with their subcategories nested.
+func (c *taxonomyController) ListCategories(ctx *gin.Context) {
+	tree, err := c.service.CategoryTree(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, tree)
+}
+
+// CreateCategory - POST /api/categories/
+func (c *taxonomyController) CreateCategory(ctx *gin.Context) {
+	var category entity.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category.ID = 0
+	category.Children = nil
+
+	if err := c.service.CreateCategory(ctx.Request.Context(), &category); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Parent category does not exist"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, category)
+}
+
+// DeleteCategory - DELETE /api/categories/:id
+func (c *taxonomyController) DeleteCategory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := c.service.DeleteCategory(ctx.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		case errors.Is(err, service.ErrCategoryInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
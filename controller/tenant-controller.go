@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/middlewares"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantController lets operators create and configure tenants, and a
+// tenant's admins define the custom attributes of its students
+type TenantController interface {
+	List(ctx *gin.Context)
+	Create(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	GetStudentAttributes(ctx *gin.Context)
+	SetStudentAttributes(ctx *gin.Context)
+}
+
+type tenantController struct {
+	service service.TenantService
+}
+
+// NewTenantController creates a new instance of the controller
+func NewTenantController(service service.TenantService) TenantController {
+	return &tenantController{
+		service: service,
+	}
+}
+
+func writeTenantError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownTenant):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+	case errors.Is(err, service.ErrTenantSlug), errors.Is(err, service.ErrTenantSettings):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTenantSlugTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// List - GET /api/admin/tenants
+func (c *tenantController) List(ctx *gin.Context) {
+	tenants, err := c.service.List(ctx.Request.Context())
+	if err != nil {
+		writeTenantError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tenants)
+}
+
+// Create - POST /api/admin/tenants
+func (c *tenantController) Create(ctx *gin.Context) {
+	var req service.TenantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := c.service.Create(ctx.Request.Context(), req)
+	if err != nil {
+		writeTenantError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, tenant)
+}
+
+// Update - PUT /api/admin/tenants/:id
+// Replaces the name and settings; the slug cannot be changed.
+func (c *tenantController) Update(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var req service.TenantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := c.service.Update(ctx.Request.Context(), id, req)
+	if err != nil {
+		writeTenantError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tenant)
+}
+
+// GetStudentAttributes - GET /api/admin/student-attributes
+func (c *tenantController) GetStudentAttributes(ctx *gin.Context) {
+	t := middlewares.CurrentTenant(ctx)
+	if t == nil {
+		writeTenantError(ctx, service.ErrUnknownTenant)
+		return
+	}
+	defs := t.Settings.StudentAttributes
+	if defs == nil {
+		defs = []entity.StudentAttribute{}
+	}
+	ctx.JSON(http.StatusOK, defs)
+}
+
+// SetStudentAttributes - PUT /api/admin/student-attributes
+// Replaces the custom attributes of the caller's tenant. Values stored for
+// removed attributes are kept but rejected on the next change.
+func (c *tenantController) SetStudentAttributes(ctx *gin.Context) {
+	var defs []entity.StudentAttribute
+	if err := ctx.ShouldBindJSON(&defs); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	defs, err := c.service.SetStudentAttributes(ctx.Request.Context(), defs)
+	if err != nil {
+		writeTenantError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, defs)
+}
@@ -1,22 +1,31 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/utils/canonicalurl"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
 	"github.com/Sarthak-D97/go_stuAPI/service"
 	"github.com/Sarthak-D97/go_stuAPI/validators"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 )
 
 type VideoController interface {
 	FindAll(ctx *gin.Context)
+	GetByID(ctx *gin.Context)
 	Save(ctx *gin.Context) error
 	Update(ctx *gin.Context) error
+	Upsert(ctx *gin.Context) error
 	Delete(ctx *gin.Context) error
 	ShowAll(ctx *gin.Context)
+	Search(ctx *gin.Context)
 }
 
 type controller struct {
@@ -34,7 +43,7 @@ func New(videoService service.VideoService) VideoController {
 }
 
 func (c *controller) FindAll(ctx *gin.Context) {
-	videos, err := c.videoService.FindAll()
+	videos, err := c.videoService.FindAll(ctx.Request.Context())
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,6 +51,20 @@ func (c *controller) FindAll(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, videos)
 }
 
+func (c *controller) GetByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	video, err := c.videoService.FindByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, video)
+}
+
 func (c *controller) Save(ctx *gin.Context) error {
 	var video entity.Video
 	err := ctx.ShouldBindJSON(&video)
@@ -54,7 +77,12 @@ func (c *controller) Save(ctx *gin.Context) error {
 		ctx.JSON(400, gin.H{"error": err.Error()})
 		return err
 	}
-	c.videoService.Save(video)
+	saved, err := c.videoService.Save(ctx.Request.Context(), video)
+	if err != nil {
+		writeVideoError(ctx, err)
+		return err
+	}
+	ctx.JSON(http.StatusCreated, saved)
 	return nil
 }
 
@@ -75,10 +103,46 @@ func (c *controller) Update(ctx *gin.Context) error {
 		ctx.JSON(400, gin.H{"error": err.Error()})
 		return err
 	}
-	c.videoService.Update(video)
+	if err := c.videoService.Update(ctx.Request.Context(), video); err != nil {
+		writeVideoError(ctx, err)
+		return err
+	}
+	updated, err := c.videoService.FindByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+	ctx.JSON(http.StatusOK, updated)
 	return nil
 
 }
+
+// Upsert - PUT /api/videos/by-url
+// Creates the video, or updates the one with the same canonical URL.
+func (c *controller) Upsert(ctx *gin.Context) error {
+	var video entity.Video
+	if err := ctx.ShouldBindJSON(&video); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+	if err := validate.Struct(video); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	saved, created, err := c.videoService.Upsert(ctx.Request.Context(), video)
+	if err != nil {
+		writeVideoError(ctx, err)
+		return err
+	}
+	if created {
+		ctx.JSON(http.StatusCreated, saved)
+		return nil
+	}
+	ctx.JSON(http.StatusOK, saved)
+	return nil
+}
+
 func (c *controller) Delete(ctx *gin.Context) error {
 	var video entity.Video
 	id, err := strconv.ParseUint(ctx.Param("id"), 0, 0)
@@ -86,11 +150,35 @@ func (c *controller) Delete(ctx *gin.Context) error {
 		return err
 	}
 	video.ID = id
-	c.videoService.Delete(video)
+	c.videoService.Delete(ctx.Request.Context(), video)
 	return nil
 }
+
+// writeVideoError maps a video service error onto an HTTP response.
+func writeVideoError(ctx *gin.Context, err error) {
+	var duplicate *service.DuplicateURLError
+	switch {
+	case errors.As(err, &duplicate):
+		link := fmt.Sprintf("/api/videos/%d", duplicate.Existing.ID)
+		ctx.Header("Location", link)
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":       err.Error(),
+			"existing_id": duplicate.Existing.ID,
+			"link":        link,
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	case errors.Is(err, canonicalurl.ErrInvalidURL):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Referenced author or category does not exist"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (c *controller) ShowAll(ctx *gin.Context) {
-	videos, err := c.videoService.FindAll()
+	videos, err := c.videoService.FindAll(ctx.Request.Context())
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -102,3 +190,52 @@ func (c *controller) ShowAll(ctx *gin.Context) {
 	}
 	ctx.HTML(http.StatusOK, "index.html", data)
 }
+
+// Search - GET /api/videos/search?q=&tag=&category=&author=&page=&page_size=
+// tag may be repeated (all must match); category includes its subcategories.
+func (c *controller) Search(ctx *gin.Context) {
+	filter := repository.VideoFilter{
+		Query: ctx.Query("q"),
+		Tags:  ctx.QueryArray("tag"),
+	}
+
+	for _, raw := range ctx.QueryArray("category") {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		filter.CategoryIDs = append(filter.CategoryIDs, id)
+	}
+
+	var err error
+	if raw := ctx.Query("author"); raw != "" {
+		if filter.AuthorID, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+			return
+		}
+	}
+	if raw := ctx.Query("page"); raw != "" {
+		if filter.Page, err = strconv.Atoi(raw); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			return
+		}
+	}
+	if raw := ctx.Query("page_size"); raw != "" {
+		if filter.PageSize, err = strconv.Atoi(raw); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size"})
+			return
+		}
+	}
+
+	result, err := c.videoService.Search(ctx.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,131 @@
+package entity
+
+import (
+	"time"
+)
+
+// Reasons recorded on a LoginAudit entry.
+const (
+	LoginOK                 = "ok"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginLocked             = "locked"
+	LoginMFAChallenge       = "mfa_challenge"
+	LoginInvalidMFA         = "invalid_mfa"
+	LoginNoRole             = "no_role"
+	LoginUnverified         = "email_unverified"
+)
+
+// LoginAudit records one login attempt, successful or not. Username is
+// stored as typed (normalized), whether or not such a user exists.
+type LoginAudit struct {
+	ID        uint64    `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID  uint64    `json:"-" gorm:"index"`
+	Username  string    `json:"username" gorm:"type:varchar(255);index"`
+	IP        string    `json:"ip" gorm:"type:varchar(64);index"`
+	UserAgent string    `json:"user_agent" gorm:"type:varchar(255)"`
+	Channel   string    `json:"channel" gorm:"type:varchar(16)"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason" gorm:"type:varchar(32)"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// MFAEnrollment holds a user's TOTP secret. It only protects logins once
+// ConfirmedAt is set, i.e. after the user proved their app produces valid
+// codes. LastStep is the time step of the last accepted code, so a code
+// cannot be used twice. Usernames are unique per tenant, so the tenant is
+// part of the key.
+type MFAEnrollment struct {
+	TenantID    uint64     `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Username    string     `json:"username" gorm:"primaryKey;type:varchar(255)"`
+	Secret      string     `json:"-" gorm:"type:varchar(64)"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	LastStep    int64      `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MFARecoveryCode is a single-use fallback for a lost authenticator. Only
+// the SHA-256 hash of the code is stored.
+type MFARecoveryCode struct {
+	ID        uint64     `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID  uint64     `json:"-" gorm:"index"`
+	Username  string     `json:"username" gorm:"type:varchar(255);index"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// User is an account that signs in through an external identity provider
+// or registered itself with an email address and password. Provider users
+// are created on the first login and their role is refreshed from the
+// provider's groups on every login; they have no PasswordHash. Registered
+// users can log in once EmailVerifiedAt is set. Users with the student role
+// are linked to their own record through StudentID, guardians to their
+// guardian record through GuardianID.
+type User struct {
+	ID              uint64         `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID        uint64         `json:"-" gorm:"uniqueIndex:idx_users_tenant_username"`
+	Username        string         `json:"username" gorm:"type:varchar(255);uniqueIndex:idx_users_tenant_username"`
+	Email           string         `json:"email" gorm:"type:varchar(255)"`
+	Name            string         `json:"name" gorm:"type:varchar(255)"`
+	Role            string         `json:"role" gorm:"type:varchar(32)"`
+	StudentID       *int           `json:"student_id,omitempty" gorm:"uniqueIndex"`
+	GuardianID      *uint64        `json:"guardian_id,omitempty" gorm:"uniqueIndex"`
+	PasswordHash    string         `json:"-" gorm:"type:varchar(255)"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	Identities      []UserIdentity `json:"identities,omitempty"`
+	LastLoginAt     *time.Time     `json:"last_login_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// UserIdentity links a user to a subject at an identity provider. Users
+// are matched by issuer and subject only, never by email.
+type UserIdentity struct {
+	ID        uint64    `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID  uint64    `json:"-" gorm:"uniqueIndex:idx_identity_tenant_issuer_subject"`
+	UserID    uint64    `json:"user_id" gorm:"index"`
+	Issuer    string    `json:"issuer" gorm:"type:varchar(255);uniqueIndex:idx_identity_tenant_issuer_subject"`
+	Subject   string    `json:"subject" gorm:"type:varchar(255);uniqueIndex:idx_identity_tenant_issuer_subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Account token purposes.
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenPasswordReset = "password_reset"
+)
+
+// AccountToken is a single-use, time-limited token mailed to a user to
+// verify their email address or reset their password. Only the SHA-256
+// hash of the token is stored.
+type AccountToken struct {
+	ID        uint64     `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID  uint64     `json:"-" gorm:"index"`
+	UserID    uint64     `json:"user_id" gorm:"index"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(32)"`
+	Hash      string     `json:"-" gorm:"type:char(64);uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIKey lets a service call the API without a user login. The key is
+// shown once at creation; only its SHA-256 hash is stored, and Prefix,
+// the public part at the start of the key, is used to look it up.
+type APIKey struct {
+	ID         uint64     `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID   uint64     `json:"-" gorm:"index"`
+	Name       string     `json:"name" gorm:"type:varchar(100)"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(32);uniqueIndex"`
+	Hash       string     `json:"-" gorm:"type:char(64)"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(255)"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty" gorm:"type:varchar(64)"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RotatedTo  *uint64    `json:"rotated_to,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
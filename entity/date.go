@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is how a Date is written in JSON and forms.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date without a time of day, such as a date of birth.
+// It is written as "2006-01-02" and stored in a date column.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the calendar date of t.
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a "2006-01-02" date.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD", s)
+	}
+	return Date{t}, nil
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+// YearsOn returns the whole years from d to on, e.g. a person's age.
+func (d Date) YearsOn(on time.Time) int {
+	years := on.Year() - d.Year()
+	if on.Month() < d.Month() || (on.Month() == d.Month() && on.Day() < d.Day()) {
+		years--
+	}
+	return years
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("date must be a string in YYYY-MM-DD format")
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// GormDataType stores dates in a date column.
+func (Date) GormDataType() string {
+	return "date"
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Date) Scan(value any) error {
+	switch v := value.(type) {
+	case time.Time:
+		*d = NewDate(v)
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into a date", value)
+	}
+	return nil
+}
+
+// scanString accepts both plain dates and the timestamps some drivers
+// return for date columns.
+func (d *Date) scanString(s string) error {
+	if len(s) >= len(DateLayout) {
+		s = s[:len(DateLayout)]
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
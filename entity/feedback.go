@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+)
+
+// Rating is a single student's 1-5 score for a video. A student has at most
+// one rating per video; rating again replaces the score.
+type Rating struct {
+	ID        uint64    `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID  uint64    `json:"-" gorm:"index"`
+	VideoID   uint64    `json:"video_id" gorm:"uniqueIndex:idx_rating_video_student"`
+	StudentID uint64    `json:"student_id" gorm:"uniqueIndex:idx_rating_video_student"`
+	Score     int       `json:"score" binding:"required,gte=1,lte=5"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommentStatus string
+
+const (
+	CommentVisible CommentStatus = "visible"
+	CommentPending CommentStatus = "pending"
+	CommentHidden  CommentStatus = "hidden"
+)
+
+// Comment is a message on a video. Replies point at their parent through
+// ParentID; Replies is filled in when a thread is assembled.
+type Comment struct {
+	ID          uint64        `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID    uint64        `json:"-" gorm:"index"`
+	VideoID     uint64        `json:"video_id" gorm:"index"`
+	StudentID   uint64        `json:"student_id"`
+	ParentID    *uint64       `json:"parent_id,omitempty" gorm:"index"`
+	Body        string        `json:"body" binding:"required,max=2000,clean-text" gorm:"type:varchar(2000)"`
+	Status      CommentStatus `json:"status" gorm:"type:varchar(16);index;default:visible"`
+	Deleted     bool          `json:"deleted"`
+	ReportCount int           `json:"report_count"`
+	ModeratedAt *time.Time    `json:"moderated_at,omitempty"`
+	EditedAt    *time.Time    `json:"edited_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Replies     []Comment     `json:"replies,omitempty" gorm:"-"`
+}
+
+// CommentReport records one student flagging a comment as abusive.
+type CommentReport struct {
+	ID        uint64    `json:"id" gorm:"primary_key;auto_increment"`
+	CommentID uint64    `json:"comment_id" gorm:"uniqueIndex:idx_report_comment_student"`
+	StudentID uint64    `json:"student_id" gorm:"uniqueIndex:idx_report_comment_student"`
+	Reason    string    `json:"reason" binding:"max=500" gorm:"type:varchar(500)"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+)
+
+// Channels a guardian can prefer to be contacted through.
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPhone = "phone"
+)
+
+// Guardian is a parent or other adult responsible for one or more
+// students. Guardians may sign in to the guardian portal once an account
+// is linked to them through User.GuardianID.
+type Guardian struct {
+	ID               uint64    `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID         uint64    `json:"-" gorm:"index"`
+	Name             string    `json:"name" binding:"required,max=255" gorm:"type:varchar(255)"`
+	Email            string    `json:"email" binding:"omitempty,email" gorm:"type:varchar(255);index"`
+	Phone            string    `json:"phone" binding:"omitempty,max=32" gorm:"type:varchar(32)"`
+	PreferredChannel string    `json:"preferred_channel" binding:"omitempty,oneof=email sms phone" gorm:"type:varchar(16);default:email"`
+	Language         string    `json:"language" binding:"omitempty,max=16" gorm:"type:varchar(16)"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// StudentGuardian links a guardian to a student. Priority orders the
+// guardians to call, 1 first; PickupAuthorized allows the guardian to
+// collect the student and EmergencyContact marks them to be called in an
+// emergency.
+type StudentGuardian struct {
+	TenantID         uint64    `json:"-" gorm:"index"`
+	StudentID        int       `json:"student_id" gorm:"primaryKey;autoIncrement:false"`
+	GuardianID       uint64    `json:"guardian_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Relationship     string    `json:"relationship" binding:"required,oneof=mother father parent stepparent grandparent sibling foster_parent legal_guardian other" gorm:"type:varchar(32)"`
+	Priority         int       `json:"priority" binding:"gte=0"`
+	PickupAuthorized bool      `json:"pickup_authorized"`
+	EmergencyContact bool      `json:"emergency_contact"`
+	Guardian         *Guardian `json:"guardian,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
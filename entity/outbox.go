@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+)
+
+// Outbox statuses.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxEmail is an email waiting to be sent. It is written in the same
+// transaction as the change it reports, so it goes out exactly when that
+// change was committed, and a background dispatcher delivers it with
+// retries. The body may carry a single-use link, so it is cleared once the
+// email is sent or given up on.
+type OutboxEmail struct {
+	ID            uint64     `json:"id" gorm:"primary_key;auto_increment"`
+	Recipient     string     `json:"recipient" gorm:"type:varchar(255)"`
+	Template      string     `json:"template" gorm:"type:varchar(64)"`
+	Subject       string     `json:"subject" gorm:"type:varchar(255)"`
+	Text          string     `json:"-" gorm:"type:text"`
+	HTML          string     `json:"-" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"type:varchar(16);index:idx_outbox_due,priority:1"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index:idx_outbox_due,priority:2"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
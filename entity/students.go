@@ -1,8 +1,107 @@
 package entity
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// Enrollment statuses of a student.
+const (
+	EnrollmentApplicant = "applicant"
+	EnrollmentEnrolled  = "enrolled"
+	EnrollmentSuspended = "suspended"
+	EnrollmentGraduated = "graduated"
+	EnrollmentWithdrawn = "withdrawn"
+)
+
+// Student is a student of a tenant. StudentNumber is generated when the
+// student is created without one. Records that predate DateOfBirth got an
+// estimate from their stored age, 1 January of the birth year, and are
+// marked BirthDateEstimated. CustomAttributes holds the fields the tenant
+// defines in its settings.
 type Student struct {
-	ID    int    `json:"id" redis:"id" gorm:"primaryKey;autoIncrement"`
-	Name  string `json:"name" validate:"required" redis:"name"`
-	Email string `json:"email" validate:"required,email" redis:"email" gorm:"uniqueIndex"`
-	Age   int    `json:"age" validate:"required" redis:"age"`
+	ID                 int            `json:"id" redis:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID           uint64         `json:"-" redis:"-" gorm:"uniqueIndex:idx_students_tenant_email;uniqueIndex:idx_students_tenant_number"`
+	StudentNumber      string         `json:"student_number" validate:"omitempty,max=32" redis:"student_number" gorm:"type:varchar(32);uniqueIndex:idx_students_tenant_number,where:student_number <> ''"`
+	Name               string         `json:"name" validate:"required" redis:"name"`
+	Email              string         `json:"email" validate:"required,email" redis:"email" gorm:"uniqueIndex:idx_students_tenant_email"`
+	DateOfBirth        *Date          `json:"date_of_birth" validate:"required" redis:"-"`
+	BirthDateEstimated bool           `json:"birth_date_estimated,omitempty" redis:"-"`
+	EnrollmentStatus   string         `json:"enrollment_status" validate:"omitempty,oneof=applicant enrolled suspended graduated withdrawn" redis:"enrollment_status" gorm:"type:varchar(16);index;default:enrolled"`
+	Addresses          []Address      `json:"addresses" validate:"dive" redis:"-" gorm:"type:jsonb;serializer:json"`
+	Phones             []Phone        `json:"phones" validate:"dive" redis:"-" gorm:"type:jsonb;serializer:json"`
+	CustomAttributes   map[string]any `json:"custom_attributes" redis:"-" gorm:"type:jsonb;serializer:json"`
+}
+
+// StudentTransition records a change of a student's enrollment status,
+// why it was made and by whom.
+type StudentTransition struct {
+	ID         uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID   uint64    `json:"-" gorm:"index"`
+	StudentID  int       `json:"student_id" gorm:"index"`
+	FromStatus string    `json:"from" gorm:"type:varchar(16)"`
+	ToStatus   string    `json:"to" gorm:"type:varchar(16)"`
+	Reason     string    `json:"reason" gorm:"type:varchar(500)"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StudentRedirect points the ID of a student that was merged away at the
+// record it was merged into.
+type StudentRedirect struct {
+	FromID    int    `gorm:"primaryKey;autoIncrement:false"`
+	TenantID  uint64 `gorm:"index"`
+	ToID      int    `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// StudentNumberSequence is the last number handed out for one student
+// number prefix of a tenant.
+type StudentNumberSequence struct {
+	TenantID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Prefix   string `gorm:"primaryKey;type:varchar(64)"`
+	Value    int64
+}
+
+// Address is one of a student's postal addresses. Country is an ISO 3166
+// alpha-2 code.
+type Address struct {
+	Kind       string `json:"kind" validate:"required,oneof=home mailing term other" binding:"required,oneof=home mailing term other"`
+	Line1      string `json:"line1" validate:"required,max=255" binding:"required,max=255"`
+	Line2      string `json:"line2,omitempty" validate:"max=255" binding:"max=255"`
+	City       string `json:"city" validate:"required,max=100" binding:"required,max=100"`
+	Region     string `json:"region,omitempty" validate:"max=100" binding:"max=100"`
+	PostalCode string `json:"postal_code,omitempty" validate:"max=20" binding:"max=20"`
+	Country    string `json:"country" validate:"required,iso3166_1_alpha2" binding:"required,iso3166_1_alpha2"`
+}
+
+// Phone is one of a student's phone numbers, in E.164 format, e.g.
+// "+442071838750".
+type Phone struct {
+	Kind   string `json:"kind" validate:"required,oneof=mobile home work other" binding:"required,oneof=mobile home work other"`
+	Number string `json:"number" validate:"required,e164" binding:"required,e164"`
+}
+
+// Age returns the student's age today, or 0 when the date of birth is
+// unknown.
+func (s Student) Age() int {
+	if s.DateOfBirth == nil {
+		return 0
+	}
+	return s.DateOfBirth.YearsOn(time.Now())
+}
+
+// MarshalJSON adds the age computed from the date of birth, so it is never
+// stale.
+func (s Student) MarshalJSON() ([]byte, error) {
+	type student Student
+	out := struct {
+		student
+		Age *int `json:"age,omitempty"`
+	}{student: student(s)}
+	if s.DateOfBirth != nil {
+		age := s.Age()
+		out.Age = &age
+	}
+	return json.Marshal(out)
 }
@@ -0,0 +1,18 @@
+package entity
+
+// Tag is a free-form label attached to videos. Names are stored lower-cased.
+type Tag struct {
+	ID       uint64 `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID uint64 `json:"-" gorm:"uniqueIndex:idx_tags_tenant_name"`
+	Name     string `json:"name" binding:"required,max=50" gorm:"type:varchar(50);uniqueIndex:idx_tags_tenant_name"`
+}
+
+// Category is a node in the video category tree. Root categories have no
+// ParentID; Children is filled in when the tree is assembled.
+type Category struct {
+	ID       uint64     `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID uint64     `json:"-" gorm:"index"`
+	Name     string     `json:"name" binding:"required,max=100" gorm:"type:varchar(100)"`
+	ParentID *uint64    `json:"parent_id,omitempty" gorm:"index"`
+	Children []Category `json:"children,omitempty" gorm:"-"`
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+)
+
+// Tenant is one campus sharing this deployment. Students, videos, users
+// and the other records that carry a TenantID belong to exactly one
+// tenant and are invisible to the others. Slug is the tenant's subdomain.
+type Tenant struct {
+	ID        uint64         `json:"id" gorm:"primary_key;auto_increment"`
+	Slug      string         `json:"slug" gorm:"type:varchar(63);uniqueIndex"`
+	Name      string         `json:"name" gorm:"type:varchar(255)"`
+	Settings  TenantSettings `json:"settings" gorm:"type:text;serializer:json"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TenantSettings overrides parts of the configuration for one tenant.
+// Unset fields fall back to the deployment's configuration.
+type TenantSettings struct {
+	// MFARequiredRoles replaces mfa.required_roles when not null; an
+	// empty list turns the requirement off for the tenant.
+	MFARequiredRoles []string `json:"mfa_required_roles"`
+	// BaseURL is where links in the tenant's emails point, e.g.
+	// "https://north.registry.example".
+	BaseURL string `json:"base_url,omitempty"`
+	// StudentCacheTTLSeconds replaces how long students stay cached.
+	StudentCacheTTLSeconds int `json:"student_cache_ttl_seconds,omitempty"`
+	// StudentNumberFormat replaces students.number_format.
+	StudentNumberFormat string `json:"student_number_format,omitempty"`
+	// StudentAttributes defines the custom attributes of the tenant's
+	// students.
+	StudentAttributes []StudentAttribute `json:"student_attributes,omitempty"`
+}
+
+// Types of a custom student attribute.
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeInteger = "integer"
+	AttributeBoolean = "boolean"
+	AttributeDate    = "date"
+	AttributeEnum    = "enum"
+)
+
+// StudentAttribute defines a custom attribute of a tenant's students,
+// stored in Student.CustomAttributes under Key. Enum attributes take one
+// of Options.
+type StudentAttribute struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label,omitempty"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
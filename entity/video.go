@@ -5,20 +5,27 @@ import (
 )
 
 type Person struct {
-	ID        uint64 `json:"id"`
+	ID        uint64 `json:"id" gorm:"primary_key;auto_increment"`
+	TenantID  uint64 `json:"-" gorm:"index"`
 	FirstName string `json:"firstname" binding:"required"`
 	LastName  string `json:"lastname" binding:"required"`
 	Age       int8   `json:"age" binding:"gte=1,lte=99"`
-	Email     string `json:"email" binding:"required,email"`
+	Email     string `json:"email" binding:"required,email" gorm:"index"`
 }
 
 type Video struct {
-	ID          uint64    `gorm:"primary_key;auto_increment" json:"id"`
-	Title       string    `json:"title" binding:"min=2,max=100" gorm:"type:varchar(100)"`
-	Description string    `json:"description" binding:"max=200" gorm:"type:varchar(200)"`
-	URL         string    `json:"url" binding:"required,url" gorm:"type:varchar(256);UNIQUE"`
-	Author      Person    `json:"author" binding:"required" gorm:"foreignkey:PersonID"`
-	PersonID    uint64    `json:"-"`
-	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID            uint64    `gorm:"primary_key;auto_increment" json:"id"`
+	TenantID      uint64    `json:"-" gorm:"uniqueIndex:idx_videos_tenant_url"`
+	Title         string    `json:"title" binding:"min=2,max=100" gorm:"type:varchar(100)"`
+	Description   string    `json:"description" binding:"max=200" gorm:"type:varchar(200)"`
+	URL           string    `json:"url" binding:"required,url" gorm:"type:varchar(256);uniqueIndex:idx_videos_tenant_url"`
+	Author        *Person   `json:"author,omitempty" binding:"required_without=PersonID" gorm:"foreignkey:PersonID"`
+	PersonID      uint64    `json:"author_id,omitempty"`
+	Tags          []Tag     `json:"tags" gorm:"many2many:video_tags"`
+	Category      *Category `json:"category,omitempty" binding:"-" gorm:"foreignkey:CategoryID"`
+	CategoryID    *uint64   `json:"category_id,omitempty" gorm:"index"`
+	RatingAverage float64   `json:"rating_average"`
+	RatingCount   int64     `json:"rating_count"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
@@ -4,17 +4,191 @@ import (
 	"flag"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type HTTPServer struct {
 	Addr string `yaml:"address" env:"ADDR" env-required:"true"`
+	// TrustedProxies may set X-Forwarded-For; with none, the client IP is
+	// always the peer address and cannot be spoofed past the rate limiter.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" env-separator:","`
+	// MetricsAddr serves /metrics on a listener of its own, to be kept off
+	// the public network. Metrics are not served when it is empty.
+	MetricsAddr string `yaml:"metrics_address" env:"METRICS_ADDR"`
+}
+
+// Cache selects and tunes the cache used by the services. Driver is one of
+// "redis", "memory" (no Redis needed) or "tiered" (local L1 + Redis L2).
+type Cache struct {
+	Driver           string        `yaml:"driver" env:"CACHE_DRIVER" env-default:"redis"`
+	RedisAddr        string        `yaml:"redis_addr" env:"REDIS_ADDR" env-default:"localhost:6379"`
+	LocalSize        int           `yaml:"local_size" env:"CACHE_LOCAL_SIZE" env-default:"10000"`
+	LocalTTL         time.Duration `yaml:"local_ttl" env:"CACHE_LOCAL_TTL" env-default:"30s"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"CACHE_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env:"CACHE_BREAKER_COOLDOWN" env-default:"30s"`
+}
+
+// Tracing configures OpenTelemetry. Exporter is "none", "stdout" (spans
+// printed to stdout for local testing) or "otlp" (OTLP over HTTP to
+// Endpoint, e.g. an OpenTelemetry Collector or Jaeger).
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"go-student-registry"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// Timeouts bounds how long a request may run before its context is
+// cancelled. Routes overrides Default per route, keyed by method and route
+// template, e.g. "GET /api/videos/search". A zero duration disables the
+// deadline.
+type Timeouts struct {
+	Default time.Duration            `yaml:"default" env:"REQUEST_TIMEOUT" env-default:"10s"`
+	Routes  map[string]time.Duration `yaml:"routes"`
+}
+
+// Logging configures the JSON logs. BodyDump is "off", "errors" (bodies
+// of 4xx/5xx exchanges only) or "all"; body fields and headers whose name
+// contains one of RedactFields are masked.
+type Logging struct {
+	Level        string   `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	BodyDump     string   `yaml:"body_dump" env:"LOG_BODY_DUMP" env-default:"off"`
+	MaxBodyBytes int      `yaml:"max_body_bytes" env:"LOG_MAX_BODY_BYTES" env-default:"4096"`
+	RedactFields []string `yaml:"redact_fields" env:"LOG_REDACT_FIELDS" env-separator:"," env-default:"password,token,secret,authorization,api_key,key,recovery_codes,uri"`
+}
+
+// Health tunes the probes. DrainDelay is how long /readyz reports failure
+// on shutdown before the server stops accepting connections.
+type Health struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" env-default:"2s"`
+	DrainDelay   time.Duration `yaml:"drain_delay" env:"HEALTH_DRAIN_DELAY" env-default:"5s"`
+}
+
+// RateLimitPolicy allows Requests per Period with bursts of up to Burst
+// (default Requests). A zero Requests leaves the group unlimited.
+type RateLimitPolicy struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+// RateLimit holds one policy per route group: "login", "public" and
+// "api_ip" count requests per client IP, "api" per JWT subject and tenant.
+// "api_ip" runs before authentication, so requests with bad tokens or
+// keys are throttled too.
+type RateLimit struct {
+	Enabled  bool                       `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	Policies map[string]RateLimitPolicy `yaml:"policies"`
+}
+
+// Lockout protects the login endpoints against password guessing. A
+// username is locked for LockDuration after MaxFailures failed attempts
+// within Window, a client IP after IPMaxFailures. Each failure is answered
+// after a delay that starts at DelayBase and doubles per failure up to
+// DelayMax.
+type Lockout struct {
+	MaxFailures   int           `yaml:"max_failures" env:"LOCKOUT_MAX_FAILURES" env-default:"5"`
+	IPMaxFailures int           `yaml:"ip_max_failures" env:"LOCKOUT_IP_MAX_FAILURES" env-default:"20"`
+	Window        time.Duration `yaml:"window" env:"LOCKOUT_WINDOW" env-default:"15m"`
+	LockDuration  time.Duration `yaml:"lock_duration" env:"LOCKOUT_DURATION" env-default:"15m"`
+	DelayBase     time.Duration `yaml:"delay_base" env:"LOCKOUT_DELAY_BASE" env-default:"250ms"`
+	DelayMax      time.Duration `yaml:"delay_max" env:"LOCKOUT_DELAY_MAX" env-default:"5s"`
+}
+
+// MFA configures TOTP two-factor login. Users whose role ("admin",
+// "user", "student" or "guardian") is listed in RequiredRoles can only
+// reach the MFA enrollment endpoints until they log in with a code.
+// Sensitive routes accept a token only within RecentAuth of such a login.
+type MFA struct {
+	Issuer        string        `yaml:"issuer" env:"MFA_ISSUER" env-default:"Student Registry"`
+	RequiredRoles []string      `yaml:"required_roles" env:"MFA_REQUIRED_ROLES" env-separator:","`
+	RecentAuth    time.Duration `yaml:"recent_auth" env:"MFA_RECENT_AUTH" env-default:"15m"`
+}
+
+// OIDC enables single sign-on through an OpenID Connect provider. Users
+// are created on their first login. GroupRoles maps provider groups to
+// "admin", "user" or "student"; users in none of them get DefaultRole, or
+// are turned away when it is empty.
+type OIDC struct {
+	Enabled      bool              `yaml:"enabled" env:"OIDC_ENABLED"`
+	Issuer       string            `yaml:"issuer" env:"OIDC_ISSUER"`
+	ClientID     string            `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string            `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string            `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes       []string          `yaml:"scopes" env:"OIDC_SCOPES" env-separator:"," env-default:"openid,email,profile,groups"`
+	GroupsClaim  string            `yaml:"groups_claim" env:"OIDC_GROUPS_CLAIM" env-default:"groups"`
+	GroupRoles   map[string]string `yaml:"group_roles"`
+	DefaultRole  string            `yaml:"default_role" env:"OIDC_DEFAULT_ROLE"`
+}
+
+// Mail configures outgoing email. Emails are queued in an outbox table
+// and delivered over SMTP by a background dispatcher every PollInterval,
+// up to MaxAttempts times with growing pauses. TLS is "starttls", "tls"
+// (implicit TLS, usually port 465) or "none" for a local catch-all server
+// such as Mailpit. Links in emails point to BaseURL.
+type Mail struct {
+	From         string        `yaml:"from" env:"MAIL_FROM" env-default:"Student Registry <no-reply@localhost>"`
+	SMTPHost     string        `yaml:"smtp_host" env:"SMTP_HOST" env-default:"localhost"`
+	SMTPPort     int           `yaml:"smtp_port" env:"SMTP_PORT" env-default:"587"`
+	SMTPUsername string        `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string        `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	TLS          string        `yaml:"tls" env:"SMTP_TLS" env-default:"starttls"`
+	BaseURL      string        `yaml:"base_url" env:"MAIL_BASE_URL" env-default:"http://localhost:8082"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"MAIL_POLL_INTERVAL" env-default:"5s"`
+	BatchSize    int           `yaml:"batch_size" env:"MAIL_BATCH_SIZE" env-default:"20"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"MAIL_MAX_ATTEMPTS" env-default:"8"`
+}
+
+// Accounts configures self-registration with email and password. Email
+// verification links expire after VerifyTTL, password reset links after
+// ResetTTL.
+type Accounts struct {
+	VerifyTTL time.Duration `yaml:"verify_ttl" env:"ACCOUNTS_VERIFY_TTL" env-default:"24h"`
+	ResetTTL  time.Duration `yaml:"reset_ttl" env:"ACCOUNTS_RESET_TTL" env-default:"1h"`
+}
+
+// Tenancy serves several tenants (campuses) from one deployment. Requests
+// to <slug>.<BaseDomain> belong to that tenant, all others to
+// DefaultTenant, which is created on first start and adopts the records
+// that predate multi-tenancy. Operators, users of the default tenant, may
+// create and configure tenants.
+type Tenancy struct {
+	BaseDomain    string   `yaml:"base_domain" env:"TENANCY_BASE_DOMAIN"`
+	DefaultTenant string   `yaml:"default_tenant" env:"TENANCY_DEFAULT_TENANT" env-default:"main"`
+	Operators     []string `yaml:"operators" env:"TENANCY_OPERATORS" env-separator:"," env-default:"admin"`
+}
+
+// Students configures student records. NumberFormat generates the number
+// of a student created without one: {year} is the current year, {yy} its
+// last two digits, {tenant} the tenant's slug in upper case and {seq} or
+// {seq:N} a counter, zero-padded to N digits, that starts again at 1
+// whenever the rest of the number changes. Transitions lists, per
+// enrollment status, the statuses a student may move on to; when empty the
+// built-in lifecycle is used.
+type Students struct {
+	NumberFormat string              `yaml:"number_format" env:"STUDENT_NUMBER_FORMAT" env-default:"{year}-{seq:5}"`
+	Transitions  map[string][]string `yaml:"transitions"`
 }
 
 type Config struct {
-	Env        string     `yaml:"env" env:"ENV" env-required:"true"`
+	Env        string `yaml:"env" env:"ENV" env-required:"true"`
 	HTTPServer `yaml:"http_server"`
+	Cache      Cache     `yaml:"cache"`
+	Tracing    Tracing   `yaml:"tracing"`
+	Timeouts   Timeouts  `yaml:"timeouts"`
+	Logging    Logging   `yaml:"logging"`
+	Health     Health    `yaml:"health"`
+	RateLimit  RateLimit `yaml:"rate_limit"`
+	Lockout    Lockout   `yaml:"lockout"`
+	MFA        MFA       `yaml:"mfa"`
+	OIDC       OIDC      `yaml:"oidc"`
+	Mail       Mail      `yaml:"mail"`
+	Accounts   Accounts  `yaml:"accounts"`
+	Tenancy    Tenancy   `yaml:"tenancy"`
+	Students   Students  `yaml:"students"`
 
 	DBHost     string `yaml:"db_host" env:"DB_HOST" env-required:"true"`
 	DBPort     int    `yaml:"db_port" env:"DB_PORT" env-required:"true"`
@@ -22,6 +196,9 @@ type Config struct {
 	DBPassword string `yaml:"db_password" env:"DB_PASSWORD" env-required:"true"`
 	DBName     string `yaml:"db_name" env:"DB_NAME" env-required:"true"`
 	DBSSLMode  string `yaml:"db_sslmode" env:"DB_SSLMODE" env-default:"disable"`
+
+	// BlockedWords overrides the default comment keyword filter when set.
+	BlockedWords []string `yaml:"blocked_words" env:"BLOCKED_WORDS" env-separator:","`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker stops calling the wrapped cache after threshold consecutive
+// failures and answers ErrUnavailable until cooldown has passed. Then a
+// single trial call decides whether to close again.
+type breaker struct {
+	next      Cache
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewBreaker wraps next with a circuit breaker.
+func NewBreaker(next Cache, threshold int, cooldown time.Duration) Cache {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &breaker{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a call may go through to the wrapped cache.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// A trial call is already in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A caller giving up or running out of its request deadline says
+	// nothing about the backend's health.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+			b.openedAt = time.Now().Add(-b.cooldown)
+		}
+		return
+	}
+
+	if err == nil || errors.Is(err, ErrMiss) {
+		if b.state != breakerClosed {
+			slog.Info("cache backend recovered, closing circuit breaker")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			slog.Warn("cache backend failing, opening circuit breaker",
+				"failures", b.failures, "cooldown", b.cooldown, "error", err)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *breaker) Get(ctx context.Context, key string) ([]byte, error) {
+	if !b.allow() {
+		return nil, ErrUnavailable
+	}
+	value, err := b.next.Get(ctx, key)
+	b.record(err)
+	return value, err
+}
+
+func (b *breaker) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if !b.allow() {
+		return ErrUnavailable
+	}
+	err := b.next.Set(ctx, key, value, ttl)
+	b.record(err)
+	return err
+}
+
+func (b *breaker) Delete(ctx context.Context, keys ...string) error {
+	if !b.allow() {
+		return ErrUnavailable
+	}
+	err := b.next.Delete(ctx, keys...)
+	b.record(err)
+	return err
+}
+
+func (b *breaker) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if !b.allow() {
+		return 0, ErrUnavailable
+	}
+	ttl, err := b.next.TTL(ctx, key)
+	b.record(err)
+	return ttl, err
+}
+
+func (b *breaker) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	if !b.allow() {
+		return 0, ErrUnavailable
+	}
+	n, err := b.next.DeletePrefix(ctx, prefix)
+	b.record(err)
+	return n, err
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("connection refused")
+
+// flaky is an in-memory cache that fails every call while down is set and
+// counts the calls that reached it.
+type flaky struct {
+	Cache
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func newFlaky() *flaky {
+	return &flaky{Cache: NewLRU(16)}
+}
+
+func (f *flaky) fail() error {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return errDown
+	}
+	return nil
+}
+
+func (f *flaky) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.Cache.Get(ctx, key)
+}
+
+func (f *flaky) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.Cache.Set(ctx, key, value, ttl)
+}
+
+func (f *flaky) Delete(ctx context.Context, keys ...string) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.Cache.Delete(ctx, keys...)
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	backend := newFlaky()
+	b := NewBreaker(backend, 2, 20*time.Millisecond)
+
+	backend.down.Store(true)
+	steps := []struct {
+		name    string
+		wait    time.Duration
+		down    bool
+		wantErr error
+		reached bool
+	}{
+		{"first failure passes through", 0, true, errDown, true},
+		{"threshold opens the breaker", 0, true, errDown, true},
+		{"open breaker skips the backend", 0, true, ErrUnavailable, false},
+		{"trial after cooldown fails", 30 * time.Millisecond, true, errDown, true},
+		{"failed trial reopens", 0, true, ErrUnavailable, false},
+		{"trial after cooldown succeeds", 30 * time.Millisecond, false, ErrMiss, true},
+		{"closed breaker calls the backend", 0, false, ErrMiss, true},
+	}
+	for _, step := range steps {
+		time.Sleep(step.wait)
+		backend.down.Store(step.down)
+		before := backend.calls.Load()
+		_, err := b.Get(ctx, "k")
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if reached := backend.calls.Load() > before; reached != step.reached {
+			t.Fatalf("%s: backend reached = %v, want %v", step.name, reached, step.reached)
+		}
+	}
+}
+
+func TestBreakerIgnoresCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := NewBreaker(canceled{NewLRU(1)}, 1, time.Hour)
+
+	for range 3 {
+		if _, err := b.Get(ctx, "k"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want context.Canceled", err)
+		}
+	}
+}
+
+// canceled fails every Get with the context's error.
+type canceled struct{ Cache }
+
+func (c canceled) Get(ctx context.Context, _ string) ([]byte, error) {
+	return nil, ctx.Err()
+}
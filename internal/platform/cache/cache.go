@@ -0,0 +1,68 @@
+// Package cache provides the byte-oriented cache used by the services, with
+// Redis, in-process LRU and two-tier implementations.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrMiss is returned by Get when the key is not cached.
+	ErrMiss = errors.New("cache: miss")
+	// ErrUnavailable is returned while the circuit breaker is open.
+	ErrUnavailable = errors.New("cache: backend unavailable")
+)
+
+// Cache stores opaque values under string keys. Callers should treat any
+// Get error as a miss and fall back to the source of truth.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// TTL returns the remaining lifetime of key, zero if it never expires,
+	// or ErrMiss if it is not cached.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// DeletePrefix removes every key starting with prefix and returns how
+	// many were removed. It is meant for admin use, not hot paths.
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+}
+
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+	DriverTiered = "tiered"
+)
+
+// New builds the cache selected by cfg.Driver. rdb may be nil for the
+// memory driver.
+func New(cfg config.Cache, rdb *redis.Client) (Cache, error) {
+	switch cfg.Driver {
+	case DriverMemory:
+		return NewLRU(cfg.LocalSize), nil
+	case DriverRedis, DriverTiered:
+		if rdb == nil {
+			return nil, fmt.Errorf("cache driver %q requires a Redis client", cfg.Driver)
+		}
+		remote := NewBreaker(NewRedis(rdb), cfg.BreakerThreshold, cfg.BreakerCooldown)
+		if cfg.Driver == DriverRedis {
+			return remote, nil
+		}
+		return NewTiered(NewLRU(cfg.LocalSize), remote, cfg.LocalTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", cfg.Driver)
+	}
+}
+
+// logUnexpected logs cache errors other than plain misses.
+func logUnexpected(op, key string, err error) {
+	if err != nil && !errors.Is(err, ErrMiss) && !errors.Is(err, ErrUnavailable) {
+		slog.Warn("cache operation failed", "op", op, "key", key, "error", err)
+	}
+}
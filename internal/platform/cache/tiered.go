@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type tieredCache struct {
+	local  Cache
+	remote Cache
+	// localTTL caps how long a node may serve a value after another node
+	// changed it, since L1 invalidations are not broadcast.
+	localTTL time.Duration
+}
+
+// NewTiered layers a process-local L1 in front of a shared L2. When L2 is
+// unavailable reads degrade to L1 alone and writes still fill L1.
+func NewTiered(local, remote Cache, localTTL time.Duration) Cache {
+	return &tieredCache{
+		local:    local,
+		remote:   remote,
+		localTTL: localTTL,
+	}
+}
+
+func (c *tieredCache) localTTLFor(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > c.localTTL {
+		return c.localTTL
+	}
+	return ttl
+}
+
+func (c *tieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := c.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		logUnexpected("get", key, err)
+		return nil, ErrMiss
+	}
+	_ = c.local.Set(ctx, key, value, c.localTTL)
+	return value, nil
+}
+
+// Set writes L1 even when L2 fails, but returns the L2 error: a value
+// that only this node holds is not seen by the others.
+func (c *tieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_ = c.local.Set(ctx, key, value, c.localTTLFor(ttl))
+	return c.remote.Set(ctx, key, value, ttl)
+}
+
+func (c *tieredCache) Delete(ctx context.Context, keys ...string) error {
+	_ = c.local.Delete(ctx, keys...)
+	return c.remote.Delete(ctx, keys...)
+}
+
+func (c *tieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if ttl, err := c.remote.TTL(ctx, key); err == nil || errors.Is(err, ErrMiss) {
+		return ttl, err
+	}
+	return c.local.TTL(ctx, key)
+}
+
+func (c *tieredCache) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	local, _ := c.local.DeletePrefix(ctx, prefix)
+	remote, err := c.remote.DeletePrefix(ctx, prefix)
+	if err != nil {
+		return local, err
+	}
+	return remote, nil
+}
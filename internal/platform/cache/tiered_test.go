@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTieredSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		down       bool
+		wantErr    error
+		wantRemote bool
+	}{
+		{"both tiers", false, nil, true},
+		{"shared tier down", true, errDown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			local, remote := NewLRU(16), newFlaky()
+			c := NewTiered(local, remote, time.Minute)
+
+			remote.down.Store(tt.down)
+			if err := c.Set(ctx, "k", []byte("v"), time.Hour); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Set error = %v, want %v", err, tt.wantErr)
+			}
+			if v, err := local.Get(ctx, "k"); err != nil || string(v) != "v" {
+				t.Errorf("local Get = %q, %v, want the value", v, err)
+			}
+			remote.down.Store(false)
+			if _, err := remote.Get(ctx, "k"); (err == nil) != tt.wantRemote {
+				t.Errorf("remote Get error = %v, want stored %v", err, tt.wantRemote)
+			}
+		})
+	}
+}
+
+func TestTieredGet(t *testing.T) {
+	ctx := context.Background()
+	local, remote := NewLRU(16), newFlaky()
+	c := NewTiered(local, remote, time.Minute)
+
+	if err := remote.Set(ctx, "shared", []byte("v"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get(ctx, "shared"); err != nil || string(v) != "v" {
+		t.Fatalf("Get = %q, %v, want the shared value", v, err)
+	}
+
+	// The value was copied into L1, so it survives the shared tier going
+	// down; a key only the shared tier would know is a miss.
+	remote.down.Store(true)
+	if v, err := c.Get(ctx, "shared"); err != nil || string(v) != "v" {
+		t.Errorf("Get with L2 down = %q, %v, want the L1 copy", v, err)
+	}
+	if _, err := c.Get(ctx, "other"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get of an unknown key with L2 down = %v, want ErrMiss", err)
+	}
+}
+
+func TestTieredLocalTTL(t *testing.T) {
+	ctx := context.Background()
+	local := NewLRU(16)
+	c := NewTiered(local, NewLRU(16), time.Minute)
+
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{time.Second, time.Second},
+		{time.Hour, time.Minute},
+		{0, time.Minute},
+	}
+	for _, tt := range tests {
+		if err := c.Set(ctx, "k", []byte("v"), tt.ttl); err != nil {
+			t.Fatal(err)
+		}
+		got, err := local.TTL(ctx, "k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got > tt.want || got < tt.want-time.Second {
+			t.Errorf("local TTL for %v = %v, want about %v", tt.ttl, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,6 +10,9 @@ import (
 
 	"github.com/Sarthak-D97/go_stuAPI/entity"
 	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/metrics"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -44,6 +48,11 @@ func debugLog(hypothesisID, message string, data map[string]any) {
 	// #endregion agent log
 }
 
+// PostgresModels lists the models stored in Postgres.
+func PostgresModels() []any {
+	return []any{&entity.Tenant{}, &entity.Student{}, &entity.StudentNumberSequence{}, &entity.StudentTransition{}, &entity.StudentRedirect{}, &entity.Guardian{}, &entity.StudentGuardian{}, &entity.LoginAudit{}, &entity.MFAEnrollment{}, &entity.MFARecoveryCode{}, &entity.User{}, &entity.UserIdentity{}, &entity.APIKey{}, &entity.AccountToken{}, &entity.OutboxEmail{}}
+}
+
 // NewPostgres creates a new GORM Postgres connection using the provided config.
 func NewPostgres(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
@@ -84,15 +93,135 @@ func NewPostgres(cfg *config.Config) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(10)
 	sqlDB.SetMaxIdleConns(5)
 
-	if err := db.AutoMigrate(&entity.Student{}); err != nil {
+	if err := metrics.InstrumentGorm(db, "postgres"); err != nil {
+		return nil, err
+	}
+	if err := tracing.InstrumentGorm(db, "postgresql"); err != nil {
+		return nil, err
+	}
+	if err := tenant.ScopeGorm(db); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(PostgresModels()...); err != nil {
 		debugLog("H1", "Postgres automigrate failed", map[string]any{
 			"error": err.Error(),
 		})
 		return nil, err
 	}
+	err = dropIndexes(db,
+		legacyIndex{&entity.Student{}, "idx_students_email"},
+		legacyIndex{&entity.User{}, "idx_users_username"},
+		legacyIndex{&entity.UserIdentity{}, "idx_identity_issuer_subject"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateStudentAges(db, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := migrateMFAKey(db); err != nil {
+		return nil, err
+	}
 
 	log.Println("connected to Postgres and ran migrations")
 	debugLog("H1", "Postgres connection and migrations succeeded", nil)
 
 	return db, nil
 }
+
+// legacyIndex is a unique index from before multi-tenancy. Its
+// replacement includes tenant_id, so the same email or name can exist once
+// per tenant.
+type legacyIndex struct {
+	model any
+	name  string
+}
+
+func dropIndexes(db *gorm.DB, indexes ...legacyIndex) error {
+	m := db.Migrator()
+	for _, idx := range indexes {
+		if !m.HasIndex(idx.model, idx.name) {
+			continue
+		}
+		if err := m.DropIndex(idx.model, idx.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateStudentAges replaces the age column students had before their
+// date of birth was stored. A student aged n gets 1 January of the year
+// n years before now, marked as estimated, which is off by less than a
+// year. The column is dropped once every age is converted.
+func migrateStudentAges(db *gorm.DB, now time.Time) error {
+	m := db.Migrator()
+	if !m.HasColumn(&entity.Student{}, "age") {
+		return nil
+	}
+	ctx := tenant.All(context.Background())
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			ID  int
+			Age int
+		}
+		err := tx.Model(&entity.Student{}).
+			Select("id, age").
+			Where("date_of_birth IS NULL AND age > 0").
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			dob := entity.NewDate(time.Date(now.Year()-row.Age, time.January, 1, 0, 0, 0, 0, time.UTC))
+			err := tx.Model(&entity.Student{}).
+				Where("id = ?", row.ID).
+				Updates(map[string]any{"date_of_birth": dob, "birth_date_estimated": true}).Error
+			if err != nil {
+				return err
+			}
+		}
+		log.Printf("converted %d student ages into estimated dates of birth", len(rows))
+		return tx.Migrator().DropColumn(&entity.Student{}, "age")
+	})
+}
+
+// migrateMFAKey moves the primary key of MFA enrollments, the username
+// alone before enrollments belonged to a tenant, to the tenant and the
+// username. Enrollments without a tenant get 0 until the default tenant
+// adopts them.
+func migrateMFAKey(db *gorm.DB) error {
+	var keyed int64
+	err := db.Raw(`SELECT COUNT(*) FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+		WHERE tc.table_name = 'mfa_enrollments' AND tc.constraint_type = 'PRIMARY KEY'
+		  AND tc.table_schema = current_schema() AND kcu.column_name = 'tenant_id'`).Scan(&keyed).Error
+	if err != nil || keyed > 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE mfa_enrollments SET tenant_id = 0 WHERE tenant_id IS NULL").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE mfa_enrollments DROP CONSTRAINT IF EXISTS mfa_enrollments_pkey, ADD PRIMARY KEY (tenant_id, username)").Error; err != nil {
+			return err
+		}
+		log.Println("keyed MFA enrollments by tenant and username")
+		return nil
+	})
+}
+
+// Pinger returns a health check that pings the connection pool behind db.
+func Pinger(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
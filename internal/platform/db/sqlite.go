@@ -0,0 +1,50 @@
+package db
+
+import (
+	"log"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/metrics"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteModels lists the models stored in the SQLite video store.
+func SQLiteModels() []any {
+	return []any{
+		&entity.Video{}, &entity.Person{}, &entity.Tag{}, &entity.Category{},
+		&entity.Rating{}, &entity.Comment{}, &entity.CommentReport{},
+	}
+}
+
+// NewSQLite opens the SQLite database backing the video store and migrates
+// the video entities.
+func NewSQLite(path string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := metrics.InstrumentGorm(db, "sqlite"); err != nil {
+		return nil, err
+	}
+	if err := tracing.InstrumentGorm(db, "sqlite"); err != nil {
+		return nil, err
+	}
+	if err := tenant.ScopeGorm(db); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(SQLiteModels()...); err != nil {
+		return nil, err
+	}
+	if err := dropIndexes(db, legacyIndex{&entity.Tag{}, "idx_tags_name"}); err != nil {
+		return nil, err
+	}
+
+	log.Println("connected to SQLite video store and ran migrations")
+
+	return db, nil
+}
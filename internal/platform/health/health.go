@@ -0,0 +1,132 @@
+// Package health tracks whether the service is started, ready for traffic
+// or draining, and runs the dependency checks behind the readiness probe.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Report statuses.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Check probes one dependency. A failing critical check makes the service
+// unready; a failing non-critical one (e.g. a cache with a fallback) only
+// degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one Check.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the body served by the readiness probe.
+type Report struct {
+	Status string                 `json:"status"`
+	Reason string                 `json:"reason,omitempty"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type Checker struct {
+	timeout  time.Duration
+	mu       sync.RWMutex
+	checks   []Check
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+// New returns a Checker that gives each check at most timeout.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a dependency check to the readiness probe.
+func (h *Checker) Register(c Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, c)
+}
+
+// MarkStarted records that connections are open and migrations have run.
+func (h *Checker) MarkStarted() {
+	h.started.Store(true)
+}
+
+// Started reports whether MarkStarted was called.
+func (h *Checker) Started() bool {
+	return h.started.Load()
+}
+
+// Drain makes the service unready for good, so load balancers stop sending
+// traffic before the server shuts down.
+func (h *Checker) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs every check concurrently and reports whether the service
+// should receive traffic.
+func (h *Checker) Ready(ctx context.Context) (Report, bool) {
+	switch {
+	case h.draining.Load():
+		return Report{Status: StatusFail, Reason: "shutting down"}, false
+	case !h.started.Load():
+		return Report{Status: StatusFail, Reason: "starting"}, false
+	}
+
+	h.mu.RLock()
+	checks := append([]Check(nil), h.checks...)
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := c.Run(ctx)
+			results[i] = CheckResult{
+				Status:    StatusOK,
+				Critical:  c.Critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = StatusFail
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	ready := true
+	for i, c := range checks {
+		report.Checks[c.Name] = results[i]
+		if results[i].Status == StatusOK {
+			continue
+		}
+		if c.Critical {
+			ready = false
+			report.Status = StatusFail
+		} else if ready {
+			report.Status = StatusDegraded
+		}
+	}
+	return report, ready
+}
@@ -0,0 +1,26 @@
+// Package lockout counts failed login attempts per key (a username or a
+// client IP) and holds temporary locks on keys that failed too often. The
+// Redis store shares that state across replicas; the in-process store is
+// for single-node deployments without Redis.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps failure counters and locks. Counters expire a window after
+// the first failure; locks expire on their own.
+type Store interface {
+	// Fail counts a failed attempt for key and returns the number of
+	// failures in the current window.
+	Fail(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock blocks key for d.
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long key stays locked, zero if it is not.
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Clear removes the failures and the lock of key.
+	Clear(ctx context.Context, key string) error
+	// Locks returns every locked key with the time left on its lock.
+	Locks(ctx context.Context) (map[string]time.Duration, error)
+}
@@ -0,0 +1,104 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	failures  int
+	resetAt   time.Time
+	lockedTil time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// NewMemory returns a store that keeps its state in this process only.
+func NewMemory() Store {
+	return &memoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// get returns the live entry for key, dropping it once both its counter
+// and its lock have expired.
+func (m *memoryStore) get(key string, now time.Time) *memoryEntry {
+	e, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	if now.After(e.resetAt) {
+		e.failures = 0
+	}
+	if e.failures == 0 && !now.Before(e.lockedTil) {
+		delete(m.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (m *memoryStore) Fail(_ context.Context, key string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	e := m.get(key, now)
+	if e == nil {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	if e.failures == 0 {
+		e.resetAt = now.Add(window)
+	}
+	e.failures++
+	return e.failures, nil
+}
+
+func (m *memoryStore) Lock(_ context.Context, key string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	e := m.get(key, now)
+	if e == nil {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	e.lockedTil = now.Add(d)
+	return nil
+}
+
+func (m *memoryStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if e := m.get(key, now); e != nil && now.Before(e.lockedTil) {
+		return e.lockedTil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (m *memoryStore) Clear(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *memoryStore) Locks(_ context.Context) (map[string]time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	locks := make(map[string]time.Duration)
+	for key := range m.entries {
+		if e := m.get(key, now); e != nil && now.Before(e.lockedTil) {
+			locks[key] = e.lockedTil.Sub(now)
+		}
+	}
+	return locks, nil
+}
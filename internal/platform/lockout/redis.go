@@ -0,0 +1,67 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	failPrefix = "lockout:fail:"
+	lockPrefix = "lockout:lock:"
+)
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedis returns a store whose counters live in Redis under
+// "lockout:fail:<key>" and whose locks live under "lockout:lock:<key>".
+func NewRedis(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func (r *redisStore) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	pipe := r.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, failPrefix+key)
+	// NX keeps the window anchored at the first failure.
+	pipe.ExpireNX(ctx, failPrefix+key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (r *redisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	return r.rdb.Set(ctx, lockPrefix+key, time.Now().Add(d).Unix(), d).Err()
+}
+
+func (r *redisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.rdb.PTTL(ctx, lockPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL answers -2 for a missing key and -1 for one without expiry.
+	return max(ttl, 0), nil
+}
+
+func (r *redisStore) Clear(ctx context.Context, key string) error {
+	return r.rdb.Del(ctx, failPrefix+key, lockPrefix+key).Err()
+}
+
+func (r *redisStore) Locks(ctx context.Context) (map[string]time.Duration, error) {
+	locks := make(map[string]time.Duration)
+	iter := r.rdb.Scan(ctx, 0, lockPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		ttl, err := r.rdb.PTTL(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			locks[strings.TrimPrefix(iter.Val(), lockPrefix)] = ttl
+		}
+	}
+	return locks, iter.Err()
+}
@@ -0,0 +1,53 @@
+// Package logging builds the application's JSON logger and carries a
+// request-scoped logger through context.Context, so service logs can be
+// matched to the request that caused them.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Body dump modes accepted in config.Logging.BodyDump.
+const (
+	BodyDumpOff    = "off"
+	BodyDumpErrors = "errors"
+	BodyDumpAll    = "all"
+)
+
+type contextKey struct{}
+
+// New returns a JSON logger writing to w at the named level ("debug",
+// "info", "warn" or "error"; anything else means info).
+func New(w io.Writer, level string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: parseLevel(level)}))
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by the request logging
+// middleware, which already carries the request ID, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
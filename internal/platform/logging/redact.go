@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces every value masked in logs.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are masked in dumped requests and responses.
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+	"X-Api-Key":     true,
+	"X-Csrf-Token":  true,
+}
+
+// Redactor masks sensitive fields in request and response dumps.
+type Redactor struct {
+	fields []string
+}
+
+// NewRedactor masks every body field whose name contains one of fields,
+// compared case-insensitively, so "password" also covers "new_password".
+func NewRedactor(fields []string) *Redactor {
+	r := &Redactor{}
+	for _, f := range fields {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			r.fields = append(r.fields, f)
+		}
+	}
+	return r
+}
+
+func (r *Redactor) sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range r.fields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers returns h flattened for logging with credentials masked.
+func (r *Redactor) Headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || r.sensitive(name) {
+			out[name] = Redacted
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// Body returns body ready for logging. JSON and form bodies have sensitive
+// fields masked; other content types are summarized by size only, since
+// they cannot be inspected safely. truncated marks a body cut at the dump
+// limit, which is then never parsed.
+func (r *Redactor) Body(contentType string, body []byte, truncated bool) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if truncated {
+		return fmt.Sprintf("<%d+ bytes, truncated>", len(body))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Sprintf("<%d bytes, invalid JSON>", len(body))
+		}
+		return r.value(v)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("<%d bytes, invalid form>", len(body))
+		}
+		out := make(map[string]any, len(values))
+		for name, vs := range values {
+			if r.sensitive(name) {
+				out[name] = Redacted
+			} else {
+				out[name] = strings.Join(vs, ", ")
+			}
+		}
+		return out
+	default:
+		return fmt.Sprintf("<%d bytes, %s>", len(body), mediaType)
+	}
+}
+
+func (r *Redactor) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for name, inner := range v {
+			if r.sensitive(name) {
+				v[name] = Redacted
+			} else {
+				v[name] = r.value(inner)
+			}
+		}
+		return v
+	case []any:
+		for i, inner := range v {
+			v[i] = r.value(inner)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Renderer turns a named template and its data into a message. Each file
+// in templates/ defines "<name>.subject", "<name>.text" and "<name>.html";
+// the HTML part is escaped with html/template, the others are plain text.
+type Renderer struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	text, err := texttemplate.New("").ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New("").ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{text: text, html: html}, nil
+}
+
+// Render renders the template name for the recipient to.
+func (r *Renderer) Render(name, to string, data any) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := r.text.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return Message{}, fmt.Errorf("render %s: %w", name, err)
+	}
+	if err := r.text.ExecuteTemplate(&text, name+".text", data); err != nil {
+		return Message{}, fmt.Errorf("render %s: %w", name, err)
+	}
+	if err := r.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, fmt.Errorf("render %s: %w", name, err)
+	}
+	return Message{
+		To:      to,
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    strings.TrimSpace(html.String()) + "\n",
+	}, nil
+}
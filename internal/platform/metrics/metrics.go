@@ -0,0 +1,21 @@
+// Package metrics instruments the database and Redis clients for
+// Prometheus. Everything registers with the default registry, which also
+// carries the Go runtime and process collectors served on /metrics.
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// register adds c to the default registry, tolerating a collector that was
+// registered before (e.g. a database opened twice).
+func register(c prometheus.Collector) error {
+	err := prometheus.Register(c)
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
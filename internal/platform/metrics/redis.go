@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+var redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "redis_command_duration_seconds",
+	Help:    "Duration of Redis commands; pipelines are labeled \"pipeline\".",
+	Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+}, []string{"command", "status"})
+
+type redisHook struct{}
+
+// InstrumentRedis records the latency of every command sent through rdb.
+func InstrumentRedis(rdb *redis.Client) error {
+	if err := register(redisCommandDuration); err != nil {
+		return err
+	}
+	rdb.AddHook(redisHook{})
+	return nil
+}
+
+func redisStatus(err error) string {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return "ok"
+	}
+	return "error"
+}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		redisCommandDuration.WithLabelValues(cmd.Name(), redisStatus(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		redisCommandDuration.WithLabelValues("pipeline", redisStatus(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many calls pass between removals of idle keys.
+const sweepEvery = 1024
+
+type memoryLimiter struct {
+	mu    sync.Mutex
+	tats  map[string]time.Time
+	calls int
+}
+
+// NewMemory returns a limiter that keeps its state in this process only.
+func NewMemory() Limiter {
+	return &memoryLimiter{tats: make(map[string]time.Time)}
+}
+
+func (m *memoryLimiter) Allow(_ context.Context, key string, policy Policy) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		for k, tat := range m.tats {
+			if tat.Before(now) {
+				delete(m.tats, k)
+			}
+		}
+	}
+
+	result, tat := decide(now, m.tats[key], policy)
+	if !tat.IsZero() {
+		m.tats[key] = tat
+	}
+	return result, nil
+}
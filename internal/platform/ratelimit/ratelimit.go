@@ -0,0 +1,80 @@
+// Package ratelimit implements GCRA rate limiting (a token bucket that
+// stores one timestamp per key) in Redis, so limits hold across replicas,
+// with an in-process variant for single-node deployments without Redis.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy allows Requests per Period on average, with bursts of up to
+// Burst requests. A zero Burst means Requests.
+type Policy struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled reports whether p limits anything.
+func (p Policy) Enabled() bool {
+	return p.Requests > 0 && p.Period > 0
+}
+
+func (p Policy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Requests
+}
+
+// interval is the time it takes to earn back one request.
+func (p Policy) interval() time.Duration {
+	return p.Period / time.Duration(p.Requests)
+}
+
+// Result describes the state of a key after a request was counted.
+type Result struct {
+	Allowed bool
+	// Limit is the burst size, the most requests allowed at once.
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a rejected caller must wait.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the key is back to its full burst.
+	ResetAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow counts one request against key under policy.
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// decide applies GCRA. tat is the key's theoretical arrival time, the
+// instant at which its bucket would be full again; a zero tat means the
+// key is unknown. It returns the result and the new tat, which is zero
+// when the request is rejected and the stored value must not change.
+func decide(now, tat time.Time, p Policy) (Result, time.Time) {
+	interval := p.interval()
+	tolerance := interval * time.Duration(p.burst())
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return Result{
+			Limit:      p.burst(),
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}, time.Time{}
+	}
+	return Result{
+		Allowed:    true,
+		Limit:      p.burst(),
+		Remaining:  int((tolerance - newTAT.Sub(now)) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}, newTAT
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDecide(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		at         time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}
+	tests := []struct {
+		name   string
+		policy Policy
+		steps  []step
+	}{
+		{
+			name:   "burst of requests then one per interval",
+			policy: Policy{Requests: 2, Period: 2 * time.Second},
+			steps: []step{
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+				{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+				{time.Second, true, 0, 0},
+				{10 * time.Second, true, 1, 0},
+			},
+		},
+		{
+			name:   "burst larger than the rate",
+			policy: Policy{Requests: 1, Period: time.Minute, Burst: 3},
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Minute},
+				{time.Minute, true, 0, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tat time.Time
+			for i, s := range tt.steps {
+				result, newTAT := decide(start.Add(s.at), tat, tt.policy)
+				if result.Allowed != s.allowed || result.Remaining != s.remaining || result.RetryAfter != s.retryAfter {
+					t.Fatalf("step %d: got allowed=%v remaining=%d retry=%v, want allowed=%v remaining=%d retry=%v",
+						i, result.Allowed, result.Remaining, result.RetryAfter, s.allowed, s.remaining, s.retryAfter)
+				}
+				if result.Limit != tt.policy.burst() {
+					t.Errorf("step %d: limit = %d, want %d", i, result.Limit, tt.policy.burst())
+				}
+				if s.allowed == newTAT.IsZero() {
+					t.Errorf("step %d: new tat = %v, want it set only for allowed requests", i, newTAT)
+				}
+				if !newTAT.IsZero() {
+					tat = newTAT
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyEnabled(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   bool
+	}{
+		{Policy{Requests: 10, Period: time.Minute}, true},
+		{Policy{Requests: 0, Period: time.Minute}, false},
+		{Policy{Requests: 10}, false},
+		{Policy{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Enabled(); got != tt.want {
+			t.Errorf("%+v.Enabled() = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryKeysApart(t *testing.T) {
+	limiter := NewMemory()
+	policy := Policy{Requests: 1, Period: time.Hour}
+	ctx := context.Background()
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"api:tenant:1:user:ada", true},
+		{"api:tenant:1:user:ada", false},
+		{"api:tenant:2:user:ada", true},
+	}
+	for _, tt := range tests {
+		result, err := limiter.Allow(ctx, tt.key, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != tt.want {
+			t.Errorf("Allow(%q) = %v, want %v", tt.key, result.Allowed, tt.want)
+		}
+	}
+}
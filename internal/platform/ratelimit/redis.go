@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript runs the same algorithm as decide atomically in Redis, using
+// the server clock so replicas with skewed clocks agree. Times are in
+// microseconds. It returns {allowed, remaining, retry_after, reset_after}.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((tolerance - (new_tat - now)) / interval), 0, new_tat - now}
+`)
+
+type redisLimiter struct {
+	rdb *redis.Client
+}
+
+// NewRedis returns a limiter whose state lives in Redis under
+// "ratelimit:<key>".
+func NewRedis(rdb *redis.Client) Limiter {
+	return &redisLimiter{rdb: rdb}
+}
+
+func (r *redisLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	interval := policy.interval()
+	tolerance := interval * time.Duration(policy.burst())
+
+	v, err := gcraScript.Run(ctx, r.rdb, []string{"ratelimit:" + key},
+		interval.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    v[0] == 1,
+		Limit:      policy.burst(),
+		Remaining:  int(v[1]),
+		RetryAfter: time.Duration(v[2]) * time.Microsecond,
+		ResetAfter: time.Duration(v[3]) * time.Microsecond,
+	}, nil
+}
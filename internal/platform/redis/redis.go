@@ -1,19 +1,37 @@
 package redisclient
 
 import (
-	"os"
+	"context"
+	"log/slog"
+	"time"
 
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/metrics"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
-// NewClient constructs a Redis client using REDIS_ADDR env var.
-func NewClient() *redis.Client {
-	addr := os.Getenv("REDIS_ADDR")
-	if addr == "" {
-		addr = "localhost:6379"
-	}
-	return redis.NewClient(&redis.Options{
-		Addr: addr,
+// NewClient constructs a Redis client for the configured cache address.
+// Timeouts are kept short so that an outage fails fast instead of stalling
+// requests; the cache circuit breaker takes it from there.
+func NewClient(cfg *config.Config) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:         cfg.Cache.RedisAddr,
+		DialTimeout:  500 * time.Millisecond,
+		ReadTimeout:  250 * time.Millisecond,
+		WriteTimeout: 250 * time.Millisecond,
+		MaxRetries:   1,
 	})
+	if err := metrics.InstrumentRedis(rdb); err != nil {
+		slog.Warn("failed to instrument Redis client", "error", err)
+	}
+	tracing.InstrumentRedis(rdb)
+	return rdb
 }
 
+// Ping checks that Redis answers within timeout.
+func Ping(rdb *redis.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return rdb.Ping(ctx).Err()
+}
@@ -0,0 +1,175 @@
+// Package tenant keeps the data of several tenants (campuses) apart in
+// shared databases. A request's tenant travels in its context; a GORM
+// plugin reads it back and scopes every statement on a model with a
+// TenantID field to that tenant, so repositories need no tenant code of
+// their own.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Column is the column that marks a model as tenant scoped.
+const Column = "tenant_id"
+
+var (
+	// ErrMissing is returned for statements on a tenant scoped model whose
+	// context names no tenant, so a forgotten tenant fails loudly instead
+	// of reading or writing every tenant's rows.
+	ErrMissing = errors.New("tenant: no tenant in context")
+	// ErrMismatch is returned when creating a record that already names
+	// another tenant than the context.
+	ErrMismatch = errors.New("tenant: record belongs to another tenant")
+)
+
+type ctxKey struct{}
+
+type allKey struct{}
+
+// WithID returns a copy of ctx scoped to tenant id.
+func WithID(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID returns the tenant ctx is scoped to.
+func ID(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(ctxKey{}).(uint64)
+	return id, ok && id != 0
+}
+
+// All returns a copy of ctx that may touch every tenant's rows, for
+// system work such as migrations. Statements under it are not scoped.
+func All(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allKey{}, true)
+}
+
+func unscoped(ctx context.Context) bool {
+	all, _ := ctx.Value(allKey{}).(bool)
+	return all
+}
+
+const scopedKey = "tenant:scoped"
+
+// scope returns the statement's tenant, or false when the statement is
+// not tenant scoped or was handled already.
+func scope(tx *gorm.DB) (uint64, bool) {
+	stmt := tx.Statement
+	if stmt.Schema == nil || stmt.Schema.LookUpField(Column) == nil || unscoped(stmt.Context) {
+		return 0, false
+	}
+	if _, done := tx.InstanceGet(scopedKey); done {
+		return 0, false
+	}
+	id, ok := ID(stmt.Context)
+	if !ok {
+		tx.AddError(ErrMissing)
+		return 0, false
+	}
+	tx.InstanceSet(scopedKey, true)
+	return id, true
+}
+
+var column = clause.Column{Table: clause.CurrentTable, Name: Column}
+
+// where limits queries to the tenant's rows.
+func where(tx *gorm.DB) {
+	if id, ok := scope(tx); ok {
+		tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: column, Value: id}}})
+	}
+}
+
+// write limits updates and deletes to the tenant's rows. Save writes
+// every column, tenant_id included, so the record is stamped as well.
+func write(tx *gorm.DB) {
+	id, ok := scope(tx)
+	if !ok {
+		return
+	}
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: column, Value: id}}})
+	if tx.Statement.ReflectValue.Kind() == reflect.Struct {
+		setTenant(tx, tx.Statement.ReflectValue, id)
+	}
+}
+
+// setTenant stamps the tenant on a record that has none yet.
+func setTenant(tx *gorm.DB, rv reflect.Value, id uint64) {
+	field := tx.Statement.Schema.LookUpField(Column)
+	current, zero := field.ValueOf(tx.Statement.Context, rv)
+	if zero {
+		if err := field.Set(tx.Statement.Context, rv, id); err != nil {
+			tx.AddError(err)
+		}
+		return
+	}
+	if current != id {
+		tx.AddError(ErrMismatch)
+	}
+}
+
+// create stamps new records with the tenant. An upsert may only update a
+// conflicting row of the same tenant, which keeps Save from overwriting
+// another tenant's record that happens to share the primary key.
+func create(tx *gorm.DB) {
+	id, ok := scope(tx)
+	if !ok {
+		return
+	}
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setTenant(tx, reflect.Indirect(rv.Index(i)), id)
+		}
+	case reflect.Struct:
+		setTenant(tx, rv, id)
+	}
+
+	if c, ok := tx.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+				Column: clause.Column{Table: tx.Statement.Table, Name: Column},
+				Value:  id,
+			})
+			tx.Statement.AddClause(onConflict)
+		}
+	}
+}
+
+// ScopeGorm registers the tenant callbacks on db. Raw SQL is not scoped;
+// it must name the tenant itself or only touch rows found through a
+// scoped query.
+func ScopeGorm(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tenant:create", create),
+		cb.Query().Before("gorm:query").Register("tenant:query", where),
+		cb.Update().Before("gorm:update").Register("tenant:update", write),
+		cb.Delete().Before("gorm:delete").Register("tenant:delete", write),
+		cb.Row().Before("gorm:row").Register("tenant:row", where),
+	)
+}
+
+// Adopt assigns rows that predate multi-tenancy, those with no tenant, to
+// tenant id. Models without a tenant column are skipped.
+func Adopt(ctx context.Context, db *gorm.DB, id uint64, models ...any) error {
+	ctx = All(ctx)
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		if stmt.Schema.LookUpField(Column) == nil {
+			continue
+		}
+		err := db.WithContext(ctx).Model(model).Where(Column+" = 0 OR "+Column+" IS NULL").Update(Column, id).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type note struct {
+	ID       uint
+	TenantID uint64
+	Body     string
+}
+
+type setting struct {
+	ID   uint
+	Body string
+}
+
+// open returns a scoped database holding note 1 of tenant 1.
+func open(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/t.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ScopeGorm(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&note{}, &setting{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(WithID(context.Background(), 1)).Create(&note{ID: 1, Body: "one"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestCrossTenantRead(t *testing.T) {
+	db := open(t)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		found   int
+		wantErr error
+	}{
+		{"same tenant", WithID(context.Background(), 1), 1, nil},
+		{"other tenant", WithID(context.Background(), 2), 0, nil},
+		{"no tenant", context.Background(), 0, ErrMissing},
+		{"all tenants", All(context.Background()), 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notes []note
+			err := db.WithContext(tt.ctx).Find(&notes).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Find error = %v, want %v", err, tt.wantErr)
+			}
+			if len(notes) != tt.found {
+				t.Errorf("Find returned %d notes, want %d", len(notes), tt.found)
+			}
+
+			var count int64
+			err = db.WithContext(tt.ctx).Model(&note{}).Where("id = ?", 1).Count(&count).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Count error = %v, want %v", err, tt.wantErr)
+			}
+			if int(count) != tt.found {
+				t.Errorf("Count = %d, want %d", count, tt.found)
+			}
+		})
+	}
+}
+
+func TestCrossTenantWrite(t *testing.T) {
+	other := WithID(context.Background(), 2)
+
+	tests := []struct {
+		name    string
+		write   func(db *gorm.DB) error
+		wantErr error
+	}{
+		{"update", func(db *gorm.DB) error {
+			return db.Model(&note{}).Where("id = ?", 1).Update("body", "changed").Error
+		}, nil},
+		{"updates on a record", func(db *gorm.DB) error {
+			return db.Model(&note{ID: 1}).Updates(map[string]any{"body": "changed"}).Error
+		}, nil},
+		{"delete", func(db *gorm.DB) error {
+			return db.Delete(&note{}, 1).Error
+		}, nil},
+		{"save", func(db *gorm.DB) error {
+			return db.Save(&note{ID: 1, Body: "changed"}).Error
+		}, nil},
+		{"upsert conflict", func(db *gorm.DB) error {
+			return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&note{ID: 1, Body: "changed"}).Error
+		}, nil},
+		{"create in another tenant", func(db *gorm.DB) error {
+			return db.Create(&note{TenantID: 1, Body: "changed"}).Error
+		}, ErrMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := open(t)
+			if err := tt.write(db.WithContext(other)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			var notes []note
+			if err := db.WithContext(All(context.Background())).Find(&notes).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(notes) != 1 || notes[0].TenantID != 1 || notes[0].Body != "one" {
+				t.Errorf("notes = %+v, want only note 1 of tenant 1 unchanged", notes)
+			}
+		})
+	}
+}
+
+func TestCreateStampsTenant(t *testing.T) {
+	db := open(t)
+	ctx := WithID(context.Background(), 2)
+
+	notes := []note{{Body: "a"}, {Body: "b"}}
+	if err := db.WithContext(ctx).Create(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range notes {
+		if n.TenantID != 2 {
+			t.Errorf("note %d has tenant %d, want 2", n.ID, n.TenantID)
+		}
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	db := open(t)
+	all := All(context.Background())
+	if err := db.WithContext(all).Create(&[]note{{ID: 2, Body: "legacy"}, {ID: 3, TenantID: 3, Body: "three"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(all).Create(&setting{ID: 1, Body: "unscoped"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Adopt(context.Background(), db, 5, &note{}, &setting{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id     uint
+		tenant uint64
+	}{
+		{1, 1},
+		{2, 5},
+		{3, 3},
+	}
+	for _, tt := range tests {
+		var n note
+		if err := db.WithContext(all).First(&n, tt.id).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n.TenantID != tt.tenant {
+			t.Errorf("note %d has tenant %d, want %d", tt.id, n.TenantID, tt.tenant)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, base32 encoded.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+	code := func(offset int64) string {
+		c, err := Code(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcSecret, code(0), 1, step, true},
+		{"previous step within skew", rfcSecret, code(-1), 1, step - 1, true},
+		{"next step within skew", rfcSecret, code(1), 1, step + 1, true},
+		{"outside skew", rfcSecret, code(2), 1, 0, false},
+		{"no skew", rfcSecret, code(-1), 0, 0, false},
+		{"surrounding spaces", rfcSecret, " " + code(0) + " ", 1, step, true},
+		{"lower case secret", strings.ToLower(rfcSecret), code(0), 0, step, true},
+		{"wrong code", rfcSecret, "000000", 1, 0, false},
+		{"too short", rfcSecret, code(0)[:5], 1, 0, false},
+		{"invalid secret", "not base32!", code(0), 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(tt.secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("two secrets are equal")
+	}
+	key, err := encoding.DecodeString(a)
+	if err != nil || len(key) != secretSize {
+		t.Errorf("secret %q decodes to %d bytes (%v), want %d", a, len(key), err, secretSize)
+	}
+}
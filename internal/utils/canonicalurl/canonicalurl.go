@@ -0,0 +1,119 @@
+// Package canonicalurl reduces the many spellings of a video link to one
+// form so that uniqueness checks compare like with like.
+package canonicalurl
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("url must be an absolute http(s) URL")
+
+// trackingParams are query parameters that never change what a link points to.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true,
+	"mc_cid": true, "mc_eid": true, "igshid": true, "si": true,
+	"feature": true, "ref": true, "ref_src": true, "_hsenc": true, "_hsmi": true,
+}
+
+var (
+	youtubeID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	vimeoID   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Canonicalize returns the canonical form of raw:
+//   - YouTube links (watch, youtu.be, embed, shorts, mobile, nocookie)
+//     become https://www.youtube.com/watch?v=ID
+//   - Vimeo links (vimeo.com, player.vimeo.com, channels, groups)
+//     become https://vimeo.com/ID
+//   - any other URL gets a lower-case scheme and host, no default port,
+//     no fragment, no tracking parameters and sorted query parameters.
+func Canonicalize(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", ErrInvalidURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", ErrInvalidURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+
+	bare := strings.TrimPrefix(strings.TrimPrefix(host, "www."), "m.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	if id := youtubeVideoID(bare, segments, u.Query()); id != "" {
+		return "https://www.youtube.com/watch?v=" + id, nil
+	}
+	if id := vimeoVideoID(bare, segments); id != "" {
+		return "https://vimeo.com/" + id, nil
+	}
+
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "/" {
+		u.Path = ""
+	}
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key.
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func youtubeVideoID(host string, segments []string, query url.Values) string {
+	var id string
+	switch host {
+	case "youtu.be":
+		if len(segments) > 0 {
+			id = segments[0]
+		}
+	case "youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		if len(segments) == 1 && segments[0] == "watch" {
+			id = query.Get("v")
+		} else if len(segments) >= 2 {
+			switch segments[0] {
+			case "embed", "shorts", "v", "live":
+				id = segments[1]
+			}
+		}
+	}
+	if youtubeID.MatchString(id) {
+		return id
+	}
+	return ""
+}
+
+func vimeoVideoID(host string, segments []string) string {
+	switch host {
+	case "vimeo.com":
+		// vimeo.com/ID, vimeo.com/channels/NAME/ID, vimeo.com/groups/NAME/videos/ID
+		for i := len(segments) - 1; i >= 0; i-- {
+			if vimeoID.MatchString(segments[i]) {
+				return segments[i]
+			}
+		}
+	case "player.vimeo.com":
+		if len(segments) >= 2 && segments[0] == "video" && vimeoID.MatchString(segments[1]) {
+			return segments[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline cancels the request context once the timeout configured for the
+// matched route has passed, so database and Redis calls stop when the
+// answer can no longer be used. The context is also cancelled when the
+// client disconnects.
+func Deadline(cfg config.Timeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := cfg.Default
+		if d, ok := cfg.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = d
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			slog.Warn("request deadline exceeded",
+				slog.String("route", c.FullPath()),
+				slog.Duration("timeout", timeout.Round(time.Millisecond)),
+			)
+		}
+	}
+}
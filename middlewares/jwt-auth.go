@@ -1,19 +1,84 @@
 package middlewares
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
 	"github.com/Sarthak-D97/go_stuAPI/service"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeySchema = "ApiKey "
+)
+
+// apiKeyFromRequest returns the key from X-API-Key or an
+// "Authorization: ApiKey ..." header.
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); len(auth) > len(apiKeySchema) && strings.EqualFold(auth[:len(apiKeySchema)], apiKeySchema) {
+		return auth[len(apiKeySchema):]
+	}
+	return ""
+}
+
+// apiKeyResource names the resource a route belongs to, the path segment
+// after /api, e.g. "students" for /api/students/:id.
+func apiKeyResource(c *gin.Context) string {
+	path := strings.TrimPrefix(c.FullPath(), "/api/")
+	resource, _, _ := strings.Cut(path, "/")
+	return resource
+}
+
+// authorizeAPIKey authenticates a request made with an API key. It sets
+// claims like a token would, with the key as the user and no admin rights,
+// and checks the key's scopes against the route.
+func authorizeAPIKey(c *gin.Context, apiKeys service.APIKeyService, secret string) {
+	key, err := apiKeys.Authenticate(c.Request.Context(), secret, c.ClientIP())
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidAPIKey) {
+			logging.FromContext(c.Request.Context()).Error("api key lookup failed", "error", err)
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+		return
+	}
+
+	write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+	if !service.APIKeyAllows(key.Scopes, apiKeyResource(c), write) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope does not cover this route"})
+		return
+	}
+
+	username := "apikey:" + key.Prefix
+	setRequestUser(c, username)
+	c.Set("claims", jwt.MapClaims{
+		"username":   username,
+		"admin":      false,
+		"api_key_id": float64(key.ID),
+		"scopes":     key.Scopes,
+	})
+	c.Next()
+}
+
 // AuthorizeJWT validates the token from the Authorization header
 // It expects the service.JWTService to be passed from main.go
-func AuthorizeJWT(jwtService service.JWTService) gin.HandlerFunc {
+// Requests may instead carry an API key in X-API-Key or as
+// "Authorization: ApiKey <key>".
+// It must run after ResolveTenant; a token is only accepted in the tenant
+// it was issued for.
+func AuthorizeJWT(jwtService service.JWTService, apiKeys service.APIKeyService, tenants service.TenantService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := apiKeyFromRequest(c); key != "" {
+			authorizeAPIKey(c, apiKeys, key)
+			return
+		}
+
 		const BEARER_SCHEMA = "Bearer "
 		authHeader := c.GetHeader("Authorization")
 
@@ -36,16 +101,21 @@ func AuthorizeJWT(jwtService service.JWTService) gin.HandlerFunc {
 
 		// 3. Check for validity
 		if err != nil || token == nil || !token.Valid {
-			// Log the actual error to the console for debugging
-			fmt.Printf("Token Validation Failed: %v\n", err)
+			// Log the actual error; the client only gets a generic message
+			logging.FromContext(c.Request.Context()).Warn("token validation failed", "error", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
 
 		// 4. Token is valid - Extract Claims
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Log for debugging (remove in production)
-			fmt.Println("User Authenticated:", claims["username"])
+			slug, _ := claims["tenant"].(string)
+			if !tokenTenant(c, tenants, slug) {
+				return
+			}
+			if username, ok := claims["username"].(string); ok {
+				setRequestUser(c, username)
+			}
 
 			// IMPORTANT: Save claims to context so Controllers can use them
 			// Usage in Controller: claims, _ := c.Get("claims")
@@ -61,3 +131,62 @@ func AuthorizeJWT(jwtService service.JWTService) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAdmin must run after AuthorizeJWT and only lets tokens with the
+// admin claim through.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get("claims")
+		if mc, ok := claims.(jwt.MapClaims); !ok || mc["admin"] != true {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireStaff must run after AuthorizeJWT and turns away student tokens,
+// which may only reach their own record.
+func RequireStaff() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !service.IsStaff(TokenRole(c)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Staff privileges required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TokenRole returns the role of the token AuthorizeJWT accepted. Tokens
+// from before the role claim and API keys get the role of their admin
+// claim.
+func TokenRole(c *gin.Context) string {
+	claims, _ := c.Get("claims")
+	mc, _ := claims.(jwt.MapClaims)
+	if role, ok := mc["role"].(string); ok && role != "" {
+		return role
+	}
+	admin, _ := mc["admin"].(bool)
+	return service.RoleFor(admin)
+}
+
+// TokenUser returns the username claim of the token AuthorizeJWT accepted,
+// or "".
+func TokenUser(c *gin.Context) string {
+	if claims, ok := c.Get("claims"); ok {
+		if mc, ok := claims.(jwt.MapClaims); ok {
+			if name, ok := mc["username"].(string); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// IsAPIKey reports whether the request was authenticated with an API key.
+func IsAPIKey(c *gin.Context) bool {
+	claims, _ := c.Get("claims")
+	mc, _ := claims.(jwt.MapClaims)
+	_, ok := mc["api_key_id"]
+	return ok
+}
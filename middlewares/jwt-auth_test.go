@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeys accepts a single secret carrying the given scopes.
+type apiKeys struct {
+	service.APIKeyService
+	secret string
+	scopes []string
+}
+
+func (k apiKeys) Authenticate(_ context.Context, secret, _ string) (*entity.APIKey, error) {
+	if secret != k.secret {
+		return nil, service.ErrInvalidAPIKey
+	}
+	return &entity.APIKey{ID: 7, Prefix: "abcd1234", Scopes: k.scopes}, nil
+}
+
+func TestAuthorizeAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", AuthorizeJWT(nil, apiKeys{secret: "sr_abcd1234_secret", scopes: []string{"students:read"}}, nil))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, requestUser(c)) }
+	api.GET("/students/:id", ok)
+	api.POST("/students", ok)
+	api.GET("/videos", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"read in scope", http.MethodGet, "/api/students/1", "X-API-Key", "sr_abcd1234_secret", http.StatusOK},
+		{"authorization header", http.MethodGet, "/api/students/1", "Authorization", "ApiKey sr_abcd1234_secret", http.StatusOK},
+		{"write outside scope", http.MethodPost, "/api/students", "X-API-Key", "sr_abcd1234_secret", http.StatusForbidden},
+		{"other resource", http.MethodGet, "/api/videos", "X-API-Key", "sr_abcd1234_secret", http.StatusForbidden},
+		{"unknown key", http.MethodGet, "/api/students/1", "X-API-Key", "sr_abcd1234_wrong", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != "apikey:abcd1234" {
+				t.Errorf("request user = %q, want apikey:abcd1234", w.Body)
+			}
+		})
+	}
+}
@@ -1,21 +1,177 @@
 package middlewares
 
 import (
-	"fmt"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
 	"time"
 
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func Logger() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] %s %s %d %s\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC822),
-			param.Method,
-			param.Path,
-			param.StatusCode,
-			param.Latency,
-		)
-	})
+const (
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds client-supplied IDs so they cannot bloat logs.
+	maxRequestIDLength = 128
+)
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts printable ASCII IDs of reasonable length.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// bodyRecorder keeps the first limit bytes written to the response.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyRecorder) capture(b []byte) {
+	room := w.limit - w.body.Len()
+	if len(b) > room {
+		b = b[:max(room, 0)]
+		w.truncated = true
+	}
+	w.body.Write(b)
+}
+
+// readRequestBody returns up to limit bytes of the request body and puts
+// them back so handlers still see the whole body.
+func readRequestBody(c *gin.Context, limit int) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+	head, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+
+	if len(head) > limit {
+		return head[:limit], true
+	}
+	return head, false
+}
+
+// requestUser names the caller from the JWT claims or the admin session.
+func requestUser(c *gin.Context) string {
+	if name := TokenUser(c); name != "" {
+		return name
+	}
+	return CurrentUser(c)
+}
+
+// setRequestUser adds user to the request-scoped logger.
+func setRequestUser(c *gin.Context, user string) {
+	ctx := c.Request.Context()
+	c.Request = c.Request.WithContext(logging.NewContext(ctx, logging.FromContext(ctx).With(slog.String("user", user))))
+}
+
+// Logger writes one JSON log line per request and makes a request-scoped
+// logger, tagged with the request ID and trace ID, available to handlers
+// and services through logging.FromContext. The request ID is taken from
+// the X-Request-ID header when valid, otherwise generated, and echoed back.
+// Depending on cfg.BodyDump, request and response bodies are included with
+// credentials redacted.
+func Logger(logger *slog.Logger, cfg config.Logging) gin.HandlerFunc {
+	redactor := logging.NewRedactor(cfg.RedactFields)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		reqLogger := logger.With(slog.String("request_id", requestID))
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			reqLogger = reqLogger.With(slog.String("trace_id", span.TraceID().String()))
+		}
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), reqLogger))
+
+		dump := cfg.BodyDump == logging.BodyDumpAll || cfg.BodyDump == logging.BodyDumpErrors
+		var reqBody []byte
+		var reqTruncated bool
+		var recorder *bodyRecorder
+		if dump {
+			reqBody, reqTruncated = readRequestBody(c, cfg.MaxBodyBytes)
+			recorder = &bodyRecorder{ResponseWriter: c.Writer, limit: cfg.MaxBodyBytes}
+			c.Writer = recorder
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int64("bytes_in", max(c.Request.ContentLength, 0)),
+			slog.Int("bytes_out", max(c.Writer.Size(), 0)),
+		}
+		if user := requestUser(c); user != "" {
+			attrs = append(attrs, slog.String("user", user))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		if dump && (cfg.BodyDump == logging.BodyDumpAll || status >= http.StatusBadRequest) {
+			attrs = append(attrs,
+				slog.Any("request_headers", redactor.Headers(c.Request.Header)),
+				slog.Any("request_body", redactor.Body(c.ContentType(), reqBody, reqTruncated)),
+				slog.Any("response_body", redactor.Body(c.Writer.Header().Get("Content-Type"), recorder.body.Bytes(), recorder.truncated)),
+			)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		reqLogger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
 }
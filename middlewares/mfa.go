@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// hasMFA reports whether the token's amr claim says a second factor was
+// used.
+func hasMFA(mc jwt.MapClaims) bool {
+	amr, _ := mc["amr"].([]any)
+	return slices.Contains(amr, any(service.AMRMFA))
+}
+
+// RequireMFA must run after AuthorizeJWT. Tokens of the listed roles, or
+// of the roles the tenant's settings list instead, are only let through
+// when they were issued after a second factor. API keys are machine
+// credentials and are not subject to it.
+func RequireMFA(roles []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPIKey(c) {
+			c.Next()
+			return
+		}
+		claims, _ := c.Get("claims")
+		mc, _ := claims.(jwt.MapClaims)
+		if slices.Contains(service.MFARequiredRoles(c.Request.Context(), roles), TokenRole(c)) && !hasMFA(mc) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "MFA required, enroll at /api/auth/mfa"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRecentMFA must run after AuthorizeJWT. It guards sensitive routes
+// by only accepting tokens issued within maxAge of a login with a second
+// factor.
+func RequireRecentMFA(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get("claims")
+		mc, _ := claims.(jwt.MapClaims)
+		iat, _ := mc["iat"].(float64)
+		if !hasMFA(mc) || time.Since(time.Unix(int64(iat), 0)) > maxAge {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Recent MFA login required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitBeforeAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := ratelimit.NewMemory()
+	policy := ratelimit.Policy{Requests: 2, Period: time.Hour}
+	api := router.Group("/api", RateLimit(limiter, "api_ip", policy), AuthorizeJWT(nil, apiKeys{secret: "sr_abcd1234_secret"}, nil))
+	api.GET("/students", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Bad keys never reach a per-user limit, so the IP limit stops them.
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, code := range want {
+		req := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+		req.Header.Set("X-API-Key", "sr_abcd1234_guess")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, code)
+		}
+	}
+}
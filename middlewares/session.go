@@ -0,0 +1,297 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookieName = "stuapi_session"
+	flashCookieName   = "stuapi_flash"
+	sessionContextKey = "session"
+	csrfFormField     = "csrf_token"
+	csrfHeader        = "X-CSRF-Token"
+)
+
+// Session is the signed state stored in the admin UI cookie. An anonymous
+// visitor still gets a session so that the login form is CSRF protected.
+type Session struct {
+	Username  string `json:"u,omitempty"`
+	CSRFToken string `json:"c"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// Flash is a one-shot message shown on the next rendered page.
+type Flash struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// signedValue wraps the payload of a signed cookie with its expiry.
+type signedValue struct {
+	Value     any   `json:"v"`
+	ExpiresAt int64 `json:"e"`
+}
+
+// SessionManager signs and verifies session cookies with an HMAC key.
+type SessionManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewSessionManager(secret string, ttl time.Duration) *SessionManager {
+	return &SessionManager{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (m *SessionManager) sign(payload string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (m *SessionManager) encode(s *Session) (string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + m.sign(payload), nil
+}
+
+func (m *SessionManager) decode(value string) (*Session, bool) {
+	payload, sig, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(m.sign(payload))) {
+		return nil, false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, false
+	}
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, false
+	}
+	if time.Now().Unix() > s.ExpiresAt {
+		return nil, false
+	}
+	return &s, true
+}
+
+// SetSignedCookie stores v as a signed, HttpOnly cookie that expires after
+// ttl. It keeps short-lived state, such as an SSO login in progress, on
+// the client between two requests.
+func (m *SessionManager) SetSignedCookie(ctx *gin.Context, name string, v any, ttl time.Duration) error {
+	b, err := json.Marshal(signedValue{Value: v, ExpiresAt: time.Now().Add(ttl).Unix()})
+	if err != nil {
+		return err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(name, payload+"."+m.sign(name+"|"+payload), int(ttl.Seconds()), "/", "", ctx.Request.TLS != nil, true)
+	return nil
+}
+
+// PopSignedCookie reads a cookie written by SetSignedCookie into v and
+// clears it. It reports false when the cookie is missing, tampered with
+// or expired.
+func (m *SessionManager) PopSignedCookie(ctx *gin.Context, name string, v any) bool {
+	value, err := ctx.Cookie(name)
+	if err != nil || value == "" {
+		return false
+	}
+	ctx.SetCookie(name, "", -1, "/", "", ctx.Request.TLS != nil, true)
+
+	payload, sig, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(m.sign(name+"|"+payload))) {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+	sv := signedValue{Value: v}
+	if err := json.Unmarshal(b, &sv); err != nil {
+		return false
+	}
+	return time.Now().Unix() <= sv.ExpiresAt
+}
+
+func (m *SessionManager) save(ctx *gin.Context, s *Session) {
+	value, err := m.encode(s)
+	if err != nil {
+		return
+	}
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(sessionCookieName, value, int(m.ttl.Seconds()), "/", "", ctx.Request.TLS != nil, true)
+	ctx.Set(sessionContextKey, s)
+}
+
+// Login replaces the current session with an authenticated one. The CSRF
+// token is rotated so a token seen before login cannot be replayed.
+func (m *SessionManager) Login(ctx *gin.Context, username string) {
+	m.save(ctx, &Session{
+		Username:  username,
+		CSRFToken: randomToken(),
+		ExpiresAt: time.Now().Add(m.ttl).Unix(),
+	})
+}
+
+// Logout downgrades the current session to an anonymous one.
+func (m *SessionManager) Logout(ctx *gin.Context) {
+	m.save(ctx, &Session{
+		CSRFToken: randomToken(),
+		ExpiresAt: time.Now().Add(m.ttl).Unix(),
+	})
+}
+
+// LoadSession makes the session available to later handlers, starting an
+// anonymous one when the cookie is missing, tampered with or expired.
+func (m *SessionManager) LoadSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if value, err := ctx.Cookie(sessionCookieName); err == nil {
+			if s, ok := m.decode(value); ok {
+				ctx.Set(sessionContextKey, s)
+				if s.Username != "" {
+					setRequestUser(ctx, s.Username)
+				}
+				ctx.Next()
+				return
+			}
+		}
+		m.Logout(ctx)
+		ctx.Next()
+	}
+}
+
+// RequireAdmin redirects anonymous visitors to loginPath. The role of a
+// signed-in user is looked up again on every request, and the session is
+// logged out once the user is no longer an admin or is locked out.
+func (m *SessionManager) RequireAdmin(loginPath string, auth service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		username := CurrentUser(ctx)
+		if username == "" {
+			ctx.Redirect(http.StatusSeeOther, loginPath)
+			ctx.Abort()
+			return
+		}
+
+		role, err := auth.SessionRole(ctx.Request.Context(), username)
+		var locked *service.LockedError
+		if err != nil && !errors.As(err, &locked) && !errors.Is(err, service.ErrInvalidCredentials) {
+			logging.FromContext(ctx.Request.Context()).Error("admin session check failed", "error", err)
+			ctx.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil || role != service.RoleAdmin {
+			logging.FromContext(ctx.Request.Context()).Warn("admin session revoked", slog.String("username", username), slog.String("role", role))
+			m.Logout(ctx)
+			SetFlash(ctx, "error", "Your session has ended, log in again")
+			ctx.Redirect(http.StatusSeeOther, loginPath)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// VerifyCSRF rejects state-changing requests whose form field or header
+// does not carry the session's CSRF token.
+func VerifyCSRF() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx.Next()
+			return
+		}
+
+		token := ctx.PostForm(csrfFormField)
+		if token == "" {
+			token = ctx.GetHeader(csrfHeader)
+		}
+		expected := CSRFToken(ctx)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func currentSession(ctx *gin.Context) *Session {
+	if v, ok := ctx.Get(sessionContextKey); ok {
+		if s, ok := v.(*Session); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// CurrentUser returns the username of the logged in admin, or "".
+func CurrentUser(ctx *gin.Context) string {
+	if s := currentSession(ctx); s != nil {
+		return s.Username
+	}
+	return ""
+}
+
+// CSRFToken returns the token forms must echo back in csrf_token.
+func CSRFToken(ctx *gin.Context) string {
+	if s := currentSession(ctx); s != nil {
+		return s.CSRFToken
+	}
+	return ""
+}
+
+// SetFlash stores a message to be shown on the next page render.
+func SetFlash(ctx *gin.Context, kind, message string) {
+	b, err := json.Marshal(Flash{Kind: kind, Message: message})
+	if err != nil {
+		return
+	}
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(flashCookieName, base64.RawURLEncoding.EncodeToString(b), 60, "/", "", ctx.Request.TLS != nil, true)
+}
+
+// PopFlash returns the pending flash message, if any, and clears it.
+func PopFlash(ctx *gin.Context) *Flash {
+	value, err := ctx.Cookie(flashCookieName)
+	if err != nil || value == "" {
+		return nil
+	}
+	ctx.SetCookie(flashCookieName, "", -1, "/", "", ctx.Request.TLS != nil, true)
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var f Flash
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil
+	}
+	return &f
+}
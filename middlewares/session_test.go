@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionRoles answers SessionRole from a fixed result.
+type sessionRoles struct {
+	service.AuthService
+	role string
+	err  error
+}
+
+func (a sessionRoles) SessionRole(context.Context, string) (string, error) {
+	return a.role, a.err
+}
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sessions := NewSessionManager("secret", time.Hour)
+	cookie, err := sessions.encode(&Session{Username: "ada", CSRFToken: "csrf", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cookie     string
+		auth       sessionRoles
+		want       int
+		wantLogout bool
+	}{
+		{"admin", cookie, sessionRoles{role: service.RoleAdmin}, http.StatusOK, false},
+		{"anonymous", "", sessionRoles{role: service.RoleAdmin}, http.StatusSeeOther, true},
+		{"demoted", cookie, sessionRoles{role: service.RoleUser}, http.StatusSeeOther, true},
+		{"locked out", cookie, sessionRoles{err: &service.LockedError{RetryAfter: time.Minute}}, http.StatusSeeOther, true},
+		{"removed", cookie, sessionRoles{err: service.ErrInvalidCredentials}, http.StatusSeeOther, true},
+		{"lookup failed", cookie, sessionRoles{err: errors.New("db down")}, http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin/students", sessions.LoadSession(), sessions.RequireAdmin("/admin/login", tt.auth), func(c *gin.Context) {
+				c.String(http.StatusOK, CurrentUser(c))
+			})
+			req := httptest.NewRequest(http.MethodGet, "/admin/students", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+
+			var loggedOut bool
+			for _, c := range w.Result().Cookies() {
+				if c.Name != sessionCookieName {
+					continue
+				}
+				s, ok := sessions.decode(c.Value)
+				loggedOut = ok && s.Username == ""
+			}
+			if loggedOut != tt.wantLogout {
+				t.Errorf("logged out = %v, want %v", loggedOut, tt.wantLogout)
+			}
+		})
+	}
+}
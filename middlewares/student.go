@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireOwnStudent must run after AuthorizeJWT. Staff may reach any
+// student record, students only the one linked to their account and
+// guardians those of their students, named by the route parameter param.
+func RequireOwnStudent(profiles service.ProfileService, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := TokenRole(c)
+		if service.IsStaff(role) {
+			c.Next()
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param(param))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+			return
+		}
+		allowed, err := profiles.CanView(c.Request.Context(), TokenUser(c), role, id)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("student lookup failed", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Student lookup failed"})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You cannot access this student record"})
+			return
+		}
+		c.Next()
+	}
+}
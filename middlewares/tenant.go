@@ -0,0 +1,131 @@
+package middlewares
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/service"
+	"github.com/gin-gonic/gin"
+)
+
+// tenantFromHostKey marks requests whose tenant was named by the host, as
+// opposed to the default tenant of the bare domain.
+const tenantFromHostKey = "tenant_from_host"
+
+// hostTenant returns the subdomain of host under baseDomain, e.g. "north"
+// for "north.registry.example:8082".
+func hostTenant(host, baseDomain string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	baseDomain = strings.ToLower(strings.Trim(baseDomain, "."))
+	if baseDomain == "" {
+		return "", false
+	}
+	return strings.CutSuffix(host, "."+baseDomain)
+}
+
+// setRequestTenant scopes the request's context, and with it every
+// repository call, to t.
+func setRequestTenant(c *gin.Context, t *entity.Tenant) {
+	ctx := service.WithTenant(c.Request.Context(), t)
+	c.Request = c.Request.WithContext(logging.NewContext(ctx, logging.FromContext(ctx).With(slog.String("tenant", t.Slug))))
+}
+
+// ResolveTenant picks the tenant of a request from its host: requests to
+// <slug>.<baseDomain> belong to that tenant, everything else to the
+// default tenant. An unknown subdomain gets 404.
+func ResolveTenant(tenants service.TenantService, baseDomain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		slug, fromHost := hostTenant(c.Request.Host, baseDomain)
+
+		var t *entity.Tenant
+		var err error
+		if fromHost {
+			t, err = tenants.BySlug(ctx, slug)
+		} else {
+			t, err = tenants.Default(ctx)
+		}
+		if errors.Is(err, service.ErrUnknownTenant) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Unknown tenant"})
+			return
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("tenant lookup failed", "error", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Tenant lookup failed"})
+			return
+		}
+
+		c.Set(tenantFromHostKey, fromHost)
+		setRequestTenant(c, t)
+		c.Next()
+	}
+}
+
+// tokenTenant moves a request on the bare domain into the tenant its token
+// was issued for. On a tenant's own subdomain only that tenant's tokens
+// are accepted. Tokens without a tenant claim predate multi-tenancy and
+// belong to the default tenant; API keys are looked up in the request's
+// tenant and need no claim.
+func tokenTenant(c *gin.Context, tenants service.TenantService, slug string) bool {
+	ctx := c.Request.Context()
+	current := service.TenantFrom(ctx)
+	if current == nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Tenant not resolved"})
+		return false
+	}
+
+	var t *entity.Tenant
+	var err error
+	if slug == "" {
+		t, err = tenants.Default(ctx)
+	} else {
+		t, err = tenants.BySlug(ctx, slug)
+	}
+	if errors.Is(err, service.ErrUnknownTenant) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return false
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("tenant lookup failed", "error", err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Tenant lookup failed"})
+		return false
+	}
+
+	if t.ID == current.ID {
+		return true
+	}
+	if c.GetBool(tenantFromHostKey) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token belongs to another tenant"})
+		return false
+	}
+	setRequestTenant(c, t)
+	return true
+}
+
+// CurrentTenant returns the tenant the request is scoped to, or nil
+// outside ResolveTenant.
+func CurrentTenant(c *gin.Context) *entity.Tenant {
+	return service.TenantFrom(c.Request.Context())
+}
+
+// RequireOperator must run after AuthorizeJWT. Tenants are managed by the
+// operators of the deployment, the listed users of the default tenant.
+func RequireOperator(defaultTenant string, operators []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := CurrentTenant(c)
+		if IsAPIKey(c) || t == nil || t.Slug != defaultTenant || !slices.Contains(operators, TokenUser(c)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Operator privileges required"})
+			return
+		}
+		c.Next()
+	}
+}
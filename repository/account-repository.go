@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+)
+
+// AccountRepository stores registered users and their email tokens. Every
+// write that mails the user queues the email in the same transaction.
+type AccountRepository interface {
+	Register(ctx context.Context, user entity.User, token entity.AccountToken, email entity.OutboxEmail) (entity.User, error)
+	IssueToken(ctx context.Context, token entity.AccountToken, email entity.OutboxEmail) error
+	GetToken(ctx context.Context, hash string) (*entity.AccountToken, error)
+	VerifyEmail(ctx context.Context, token entity.AccountToken) error
+	ResetPassword(ctx context.Context, token entity.AccountToken, passwordHash string, email entity.OutboxEmail) error
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+// Register creates the user and queues the verification email with its
+// token.
+func (r *accountRepository) Register(ctx context.Context, user entity.User, token entity.AccountToken, email entity.OutboxEmail) (entity.User, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		token.UserID = user.ID
+		if err := tx.Create(&token).Error; err != nil {
+			return err
+		}
+		return enqueueEmail(tx, email)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// expireTokens uses up the user's open tokens of a purpose, so only the
+// newest link works.
+func expireTokens(tx *gorm.DB, userID uint64, purpose string) error {
+	return tx.Model(&entity.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+// IssueToken replaces the user's open tokens of the same purpose with
+// token and queues the email carrying it.
+func (r *accountRepository) IssueToken(ctx context.Context, token entity.AccountToken, email entity.OutboxEmail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := expireTokens(tx, token.UserID, token.Purpose); err != nil {
+			return err
+		}
+		if err := tx.Create(&token).Error; err != nil {
+			return err
+		}
+		return enqueueEmail(tx, email)
+	})
+}
+
+func (r *accountRepository) GetToken(ctx context.Context, hash string) (*entity.AccountToken, error) {
+	var token entity.AccountToken
+	if err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// useToken marks the token used. It returns gorm.ErrRecordNotFound when
+// the token was used or expired in the meantime, so two requests racing
+// with the same token cannot both succeed.
+func useToken(tx *gorm.DB, token entity.AccountToken) error {
+	now := time.Now()
+	res := tx.Model(&entity.AccountToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+		Update("used_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *accountRepository) VerifyEmail(ctx context.Context, token entity.AccountToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := useToken(tx, token); err != nil {
+			return err
+		}
+		return tx.Model(&entity.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+}
+
+// ResetPassword sets the new password, uses up every open reset token of
+// the user and queues the notice that the password changed. A reset link
+// proves the user reads the mailbox, so it verifies the address too.
+func (r *accountRepository) ResetPassword(ctx context.Context, token entity.AccountToken, passwordHash string, email entity.OutboxEmail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := useToken(tx, token); err != nil {
+			return err
+		}
+		if err := expireTokens(tx, token.UserID, entity.TokenPasswordReset); err != nil {
+			return err
+		}
+		err := tx.Model(&entity.User{}).
+			Where("id = ?", token.UserID).
+			Updates(map[string]any{
+				"password_hash":     passwordHash,
+				"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return enqueueEmail(tx, email)
+	})
+}
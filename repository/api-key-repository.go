@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	GetByID(ctx context.Context, id uint64) (*entity.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	Rotate(ctx context.Context, old entity.APIKey, replacement entity.APIKey) (entity.APIKey, error)
+	Revoke(ctx context.Context, id uint64) error
+	TouchLastUsed(ctx context.Context, id uint64, ip string) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	if err := r.db.WithContext(ctx).Create(&key).Error; err != nil {
+		return entity.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint64) (*entity.APIKey, error) {
+	var key entity.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	if err := r.db.WithContext(ctx).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Rotate stores the replacement and updates the old key's expiry and
+// revocation in one transaction, pointing it at its successor.
+func (r *apiKeyRepository) Rotate(ctx context.Context, old entity.APIKey, replacement entity.APIKey) (entity.APIKey, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&replacement).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.APIKey{}).Where("id = ?", old.ID).Updates(map[string]any{
+			"expires_at": old.ExpiresAt,
+			"revoked_at": old.RevokedAt,
+			"rotated_to": replacement.ID,
+		}).Error
+	})
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+	return replacement, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint64) error {
+	res := r.db.WithContext(ctx).Model(&entity.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint64, ip string) error {
+	return r.db.WithContext(ctx).Model(&entity.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"last_used_at": time.Now(), "last_used_ip": ip}).Error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+)
+
+// LoginAuditFilter narrows the audit listing. Empty fields match anything.
+type LoginAuditFilter struct {
+	Username string
+	IP       string
+	Success  *bool
+	Limit    int
+}
+
+type AuthRepository interface {
+	RecordLogin(ctx context.Context, audit entity.LoginAudit) error
+	LoginAudit(ctx context.Context, filter LoginAuditFilter) ([]entity.LoginAudit, error)
+}
+
+type authRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthRepository(db *gorm.DB) AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) RecordLogin(ctx context.Context, audit entity.LoginAudit) error {
+	return r.db.WithContext(ctx).Create(&audit).Error
+}
+
+// LoginAudit lists the matching attempts, newest first.
+func (r *authRepository) LoginAudit(ctx context.Context, filter LoginAuditFilter) ([]entity.LoginAudit, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(filter.Limit)
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.Success != nil {
+		query = query.Where("success = ?", *filter.Success)
+	}
+
+	var audits []entity.LoginAudit
+	if err := query.Find(&audits).Error; err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeedbackRepository interface {
+	// UpsertRating stores the student's score and refreshes the aggregate
+	// columns on the video.
+	UpsertRating(ctx context.Context, rating entity.Rating) error
+	RatingDistribution(ctx context.Context, videoID uint64) (map[int]int64, error)
+
+	CreateComment(ctx context.Context, comment entity.Comment) (entity.Comment, error)
+	GetComment(ctx context.Context, id uint64) (*entity.Comment, error)
+	ListComments(ctx context.Context, videoID uint64, statuses ...entity.CommentStatus) ([]entity.Comment, error)
+	UpdateComment(ctx context.Context, comment entity.Comment) error
+	// AddReport records a report once per student and returns the comment's
+	// new report count; created is false when the student already reported.
+	AddReport(ctx context.Context, report entity.CommentReport) (count int, created bool, err error)
+	ModerationQueue(ctx context.Context) ([]entity.Comment, error)
+	SetCommentStatus(ctx context.Context, id uint64, status entity.CommentStatus, moderatedAt *time.Time) error
+	// MoveStudent gives the ratings, comments and reports of one student to
+	// another. Where both rated a video or reported a comment, the second
+	// student's rating or report is kept.
+	MoveStudent(ctx context.Context, from, into uint64) error
+}
+
+type feedbackRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedbackRepository(db *gorm.DB) FeedbackRepository {
+	return &feedbackRepository{db: db}
+}
+
+func (r *feedbackRepository) UpsertRating(ctx context.Context, rating entity.Rating) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "video_id"}, {Name: "student_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "updated_at"}),
+		}).Create(&rating).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`UPDATE videos SET
+			rating_average = COALESCE((SELECT AVG(score) FROM ratings WHERE video_id = ?), 0),
+			rating_count = (SELECT COUNT(*) FROM ratings WHERE video_id = ?)
+			WHERE id = ?`, rating.VideoID, rating.VideoID, rating.VideoID).Error
+	})
+}
+
+func (r *feedbackRepository) RatingDistribution(ctx context.Context, videoID uint64) (map[int]int64, error) {
+	var rows []struct {
+		Score int
+		Count int64
+	}
+	err := r.db.WithContext(ctx).Model(&entity.Rating{}).
+		Select("score, COUNT(*) AS count").
+		Where("video_id = ?", videoID).
+		Group("score").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, row := range rows {
+		distribution[row.Score] = row.Count
+	}
+	return distribution, nil
+}
+
+func (r *feedbackRepository) CreateComment(ctx context.Context, comment entity.Comment) (entity.Comment, error) {
+	if err := r.db.WithContext(ctx).Create(&comment).Error; err != nil {
+		return entity.Comment{}, err
+	}
+	return comment, nil
+}
+
+func (r *feedbackRepository) GetComment(ctx context.Context, id uint64) (*entity.Comment, error) {
+	var comment entity.Comment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *feedbackRepository) ListComments(ctx context.Context, videoID uint64, statuses ...entity.CommentStatus) ([]entity.Comment, error) {
+	var comments []entity.Comment
+	q := r.db.WithContext(ctx).Where("video_id = ?", videoID)
+	if len(statuses) > 0 {
+		q = q.Where("status IN ?", statuses)
+	}
+	err := q.Order("created_at, id").Find(&comments).Error
+	return comments, err
+}
+
+func (r *feedbackRepository) UpdateComment(ctx context.Context, comment entity.Comment) error {
+	return r.db.WithContext(ctx).Save(&comment).Error
+}
+
+func (r *feedbackRepository) AddReport(ctx context.Context, report entity.CommentReport) (int, bool, error) {
+	var count int
+	var created bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+		if res.Error != nil {
+			return res.Error
+		}
+		created = res.RowsAffected > 0
+
+		if created {
+			err := tx.Model(&entity.Comment{}).
+				Where("id = ?", report.CommentID).
+				Update("report_count", gorm.Expr("report_count + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&entity.Comment{}).
+			Select("report_count").
+			Where("id = ?", report.CommentID).
+			Scan(&count).Error
+	})
+	return count, created, err
+}
+
+// ModerationQueue lists comments awaiting review and visible comments that
+// were reported but never reviewed, most reported first.
+func (r *feedbackRepository) ModerationQueue(ctx context.Context) ([]entity.Comment, error) {
+	var comments []entity.Comment
+	err := r.db.WithContext(ctx).
+		Where("status = ?", entity.CommentPending).
+		Or("status = ? AND report_count > 0 AND moderated_at IS NULL", entity.CommentVisible).
+		Order("report_count DESC, created_at").
+		Find(&comments).Error
+	return comments, err
+}
+
+func (r *feedbackRepository) SetCommentStatus(ctx context.Context, id uint64, status entity.CommentStatus, moderatedAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Comment{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": status, "moderated_at": moderatedAt}).Error
+}
+
+func (r *feedbackRepository) MoveStudent(ctx context.Context, from, into uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var videoIDs []uint64
+		err := tx.Model(&entity.Rating{}).
+			Where("student_id = ? AND video_id IN (?)", from,
+				tx.Model(&entity.Rating{}).Select("video_id").Where("student_id = ?", into)).
+			Pluck("video_id", &videoIDs).Error
+		if err != nil {
+			return err
+		}
+		if len(videoIDs) > 0 {
+			if err := tx.Where("student_id = ? AND video_id IN ?", from, videoIDs).Delete(&entity.Rating{}).Error; err != nil {
+				return err
+			}
+			for _, videoID := range videoIDs {
+				err := tx.Exec(`UPDATE videos SET
+					rating_average = COALESCE((SELECT AVG(score) FROM ratings WHERE video_id = ?), 0),
+					rating_count = (SELECT COUNT(*) FROM ratings WHERE video_id = ?)
+					WHERE id = ?`, videoID, videoID, videoID).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Model(&entity.Rating{}).Where("student_id = ?", from).Update("student_id", into).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Comment{}).Where("student_id = ?", from).Update("student_id", into).Error; err != nil {
+			return err
+		}
+
+		var commentIDs []uint64
+		err = tx.Model(&entity.CommentReport{}).
+			Where("student_id = ? AND comment_id IN (?)", from,
+				tx.Model(&entity.CommentReport{}).Select("comment_id").Where("student_id = ?", into)).
+			Pluck("comment_id", &commentIDs).Error
+		if err != nil {
+			return err
+		}
+		if len(commentIDs) > 0 {
+			if err := tx.Where("student_id = ? AND comment_id IN ?", from, commentIDs).Delete(&entity.CommentReport{}).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&entity.Comment{}).
+				Where("id IN ?", commentIDs).
+				Update("report_count", gorm.Expr("report_count - 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&entity.CommentReport{}).Where("student_id = ?", from).Update("student_id", into).Error
+	})
+}
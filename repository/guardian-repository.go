@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GuardianRepository interface {
+	Create(ctx context.Context, guardian entity.Guardian) (entity.Guardian, error)
+	GetByID(ctx context.Context, id uint64) (*entity.Guardian, error)
+	FindByEmail(ctx context.Context, email string) ([]entity.Guardian, error)
+	List(ctx context.Context) ([]entity.Guardian, error)
+	Update(ctx context.Context, guardian entity.Guardian) error
+	Delete(ctx context.Context, id uint64) error
+	ForStudent(ctx context.Context, studentID int) ([]entity.StudentGuardian, error)
+	Students(ctx context.Context, guardianID uint64) ([]entity.Student, error)
+	IsLinked(ctx context.Context, studentID int, guardianID uint64) (bool, error)
+	NextPriority(ctx context.Context, studentID int) (int, error)
+	Link(ctx context.Context, link entity.StudentGuardian) error
+	Unlink(ctx context.Context, studentID int, guardianID uint64) error
+}
+
+type guardianRepository struct {
+	db *gorm.DB
+}
+
+func NewGuardianRepository(db *gorm.DB) GuardianRepository {
+	return &guardianRepository{db: db}
+}
+
+func (r *guardianRepository) Create(ctx context.Context, guardian entity.Guardian) (entity.Guardian, error) {
+	if err := r.db.WithContext(ctx).Create(&guardian).Error; err != nil {
+		return entity.Guardian{}, err
+	}
+	return guardian, nil
+}
+
+func (r *guardianRepository) GetByID(ctx context.Context, id uint64) (*entity.Guardian, error) {
+	var guardian entity.Guardian
+	if err := r.db.WithContext(ctx).First(&guardian, id).Error; err != nil {
+		return nil, err
+	}
+	return &guardian, nil
+}
+
+// FindByEmail matches case-insensitively, ordered by ID.
+func (r *guardianRepository) FindByEmail(ctx context.Context, email string) ([]entity.Guardian, error) {
+	var guardians []entity.Guardian
+	err := r.db.WithContext(ctx).Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).
+		Order("id").
+		Find(&guardians).Error
+	return guardians, err
+}
+
+func (r *guardianRepository) List(ctx context.Context) ([]entity.Guardian, error) {
+	var guardians []entity.Guardian
+	if err := r.db.WithContext(ctx).Order("id").Find(&guardians).Error; err != nil {
+		return nil, err
+	}
+	return guardians, nil
+}
+
+func (r *guardianRepository) Update(ctx context.Context, guardian entity.Guardian) error {
+	return r.db.WithContext(ctx).Save(&guardian).Error
+}
+
+// Delete removes the guardian with its student links and detaches its
+// portal account.
+func (r *guardianRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("guardian_id = ?", id).Delete(&entity.StudentGuardian{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.User{}).Where("guardian_id = ?", id).Update("guardian_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Guardian{}, id).Error
+	})
+}
+
+// ForStudent returns the student's guardian links with their guardians,
+// in contact order.
+func (r *guardianRepository) ForStudent(ctx context.Context, studentID int) ([]entity.StudentGuardian, error) {
+	var links []entity.StudentGuardian
+	err := r.db.WithContext(ctx).Preload("Guardian").
+		Where("student_id = ?", studentID).
+		Order("priority, guardian_id").
+		Find(&links).Error
+	return links, err
+}
+
+// Students returns the students linked to the guardian, ordered by ID.
+func (r *guardianRepository) Students(ctx context.Context, guardianID uint64) ([]entity.Student, error) {
+	var ids []int
+	err := r.db.WithContext(ctx).Model(&entity.StudentGuardian{}).
+		Where("guardian_id = ?", guardianID).
+		Pluck("student_id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return []entity.Student{}, err
+	}
+
+	var students []entity.Student
+	err = r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&students).Error
+	return students, err
+}
+
+func (r *guardianRepository) IsLinked(ctx context.Context, studentID int, guardianID uint64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.StudentGuardian{}).
+		Where("student_id = ? AND guardian_id = ?", studentID, guardianID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// NextPriority returns the priority after the student's last guardian.
+func (r *guardianRepository) NextPriority(ctx context.Context, studentID int) (int, error) {
+	var last int
+	err := r.db.WithContext(ctx).Model(&entity.StudentGuardian{}).
+		Where("student_id = ?", studentID).
+		Select("COALESCE(MAX(priority), 0)").
+		Scan(&last).Error
+	return last + 1, err
+}
+
+// Link creates the link or replaces the details of an existing one.
+func (r *guardianRepository) Link(ctx context.Context, link entity.StudentGuardian) error {
+	link.Guardian = nil
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_id"}, {Name: "guardian_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"relationship", "priority", "pickup_authorized", "emergency_contact", "updated_at"}),
+	}).Create(&link).Error
+}
+
+// Unlink removes the link, or returns gorm.ErrRecordNotFound when there
+// is none.
+func (r *guardianRepository) Unlink(ctx context.Context, studentID int, guardianID uint64) error {
+	res := r.db.WithContext(ctx).
+		Where("student_id = ? AND guardian_id = ?", studentID, guardianID).
+		Delete(&entity.StudentGuardian{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository interface {
+	GetEnrollment(ctx context.Context, username string) (*entity.MFAEnrollment, error)
+	SaveEnrollment(ctx context.Context, enrollment entity.MFAEnrollment) error
+	Confirm(ctx context.Context, username string, step int64, codeHashes []string) error
+	UseStep(ctx context.Context, username string, step int64) (bool, error)
+	Delete(ctx context.Context, username string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, username string) (int64, error)
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetEnrollment(ctx context.Context, username string) (*entity.MFAEnrollment, error) {
+	var enrollment entity.MFAEnrollment
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&enrollment).Error; err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// SaveEnrollment creates the enrollment or replaces an unconfirmed one.
+func (r *mfaRepository) SaveEnrollment(ctx context.Context, enrollment entity.MFAEnrollment) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_step", "updated_at"}),
+	}).Create(&enrollment).Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, username string, codeHashes []string) error {
+	if err := tx.Where("username = ?", username).Delete(&entity.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]entity.MFARecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = entity.MFARecoveryCode{Username: username, CodeHash: hash}
+	}
+	return tx.Create(&codes).Error
+}
+
+// Confirm activates a pending enrollment, records the step of the code
+// that confirmed it and stores a fresh set of recovery codes, all in one
+// transaction. It fails with gorm.ErrRecordNotFound when there is no
+// pending enrollment.
+func (r *mfaRepository) Confirm(ctx context.Context, username string, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entity.MFAEnrollment{}).
+			Where("username = ? AND confirmed_at IS NULL", username).
+			Updates(map[string]any{"confirmed_at": time.Now(), "last_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, username, codeHashes)
+	})
+}
+
+// UseStep records step as the last accepted code. It reports false when a
+// code of this or a later step was already accepted, which also settles
+// two concurrent logins racing with the same code.
+func (r *mfaRepository) UseStep(ctx context.Context, username string, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entity.MFAEnrollment{}).
+		Where("username = ? AND last_step < ?", username, step).
+		Update("last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *mfaRepository) Delete(ctx context.Context, username string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ?", username).Delete(&entity.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("username = ?", username).Delete(&entity.MFAEnrollment{}).Error
+	})
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, username, codeHashes)
+	})
+}
+
+// UseRecoveryCode marks the code as used and reports whether it was valid
+// and still unused.
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entity.MFARecoveryCode{}).
+		Where("username = ? AND code_hash = ? AND used_at IS NULL", username, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.MFARecoveryCode{}).
+		Where("username = ? AND used_at IS NULL", username).
+		Count(&count).Error
+	return count, err
+}
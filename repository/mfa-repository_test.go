@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openDB returns a tenant scoped SQLite database with the given models.
+func openDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/t.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tenant.ScopeGorm(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMFATenants(t *testing.T) {
+	repo := NewMFARepository(openDB(t, &entity.MFAEnrollment{}, &entity.MFARecoveryCode{}))
+	one := tenant.WithID(context.Background(), 1)
+	two := tenant.WithID(context.Background(), 2)
+
+	if err := repo.SaveEnrollment(one, entity.MFAEnrollment{Username: "ada", Secret: "ONE"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Confirm(one, "ada", 1, []string{"hash-one"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveEnrollment(two, entity.MFAEnrollment{Username: "ada", Secret: "TWO"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		check func() (bool, error)
+	}{
+		{"each tenant reads its own secret", func() (bool, error) {
+			e1, err := repo.GetEnrollment(one, "ada")
+			if err != nil {
+				return false, err
+			}
+			e2, err := repo.GetEnrollment(two, "ada")
+			if err != nil {
+				return false, err
+			}
+			return e1.Secret == "ONE" && e2.Secret == "TWO" && e1.ConfirmedAt != nil && e2.ConfirmedAt == nil, nil
+		}},
+		{"recovery codes are not counted in another tenant", func() (bool, error) {
+			n, err := repo.CountRecoveryCodes(two, "ada")
+			return n == 0, err
+		}},
+		{"recovery codes cannot be used from another tenant", func() (bool, error) {
+			used, err := repo.UseRecoveryCode(two, "ada", "hash-one")
+			return !used, err
+		}},
+		{"deleting in one tenant keeps the other", func() (bool, error) {
+			if err := repo.Delete(two, "ada"); err != nil {
+				return false, err
+			}
+			n, err := repo.CountRecoveryCodes(one, "ada")
+			if err != nil {
+				return false, err
+			}
+			_, err = repo.GetEnrollment(one, "ada")
+			return n == 1, err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := tt.check()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Error("tenants are not kept apart")
+			}
+		})
+	}
+}
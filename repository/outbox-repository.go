@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, email entity.OutboxEmail) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxEmail, error)
+	MarkSent(ctx context.Context, id uint64) error
+	MarkFailed(ctx context.Context, id uint64, lastError string, retryAt *time.Time) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// enqueueEmail adds email to the outbox through tx, so callers can queue
+// it in the transaction of the change it reports.
+func enqueueEmail(tx *gorm.DB, email entity.OutboxEmail) error {
+	email.Status = entity.OutboxPending
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = time.Now()
+	}
+	return tx.Create(&email).Error
+}
+
+func (r *outboxRepository) Enqueue(ctx context.Context, email entity.OutboxEmail) error {
+	return enqueueEmail(r.db.WithContext(ctx), email)
+}
+
+// Claim picks up to limit due emails and pushes their next attempt back by
+// lease, so other replicas polling at the same time skip them and an email
+// whose sender crashed is retried once the lease runs out.
+func (r *outboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxEmail, error) {
+	var emails []entity.OutboxEmail
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", entity.OutboxPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&emails).Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
+		ids := make([]uint64, len(emails))
+		for i := range emails {
+			ids[i] = emails[i].ID
+			emails[i].Attempts++
+		}
+		return tx.Model(&entity.OutboxEmail{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": now.Add(lease),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Model(&entity.OutboxEmail{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     entity.OutboxSent,
+			"sent_at":    time.Now(),
+			"last_error": "",
+			"text":       "",
+			"html":       "",
+		}).Error
+}
+
+// MarkFailed records a failed attempt. The email is retried at retryAt, or
+// given up on when retryAt is nil.
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uint64, lastError string, retryAt *time.Time) error {
+	updates := map[string]any{"last_error": lastError}
+	if retryAt != nil {
+		updates["next_attempt_at"] = *retryAt
+	} else {
+		updates["status"] = entity.OutboxFailed
+		updates["text"] = ""
+		updates["html"] = ""
+	}
+	return r.db.WithContext(ctx).Model(&entity.OutboxEmail{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+)
+
+type PersonRepository interface {
+	Create(ctx context.Context, person entity.Person) (entity.Person, error)
+	GetByID(ctx context.Context, id uint64) (*entity.Person, error)
+	FindByEmail(ctx context.Context, email string) ([]entity.Person, error)
+	List(ctx context.Context) ([]entity.Person, error)
+	Update(ctx context.Context, person entity.Person) error
+	Delete(ctx context.Context, id uint64) error
+	CountVideos(ctx context.Context, id uint64) (int64, error)
+	DuplicateEmails(ctx context.Context) ([]string, error)
+	Merge(ctx context.Context, targetID, sourceID uint64) error
+}
+
+type personRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonRepository(db *gorm.DB) PersonRepository {
+	return &personRepository{db: db}
+}
+
+func (r *personRepository) Create(ctx context.Context, person entity.Person) (entity.Person, error) {
+	if err := r.db.WithContext(ctx).Create(&person).Error; err != nil {
+		return entity.Person{}, err
+	}
+	return person, nil
+}
+
+func (r *personRepository) GetByID(ctx context.Context, id uint64) (*entity.Person, error) {
+	var person entity.Person
+	if err := r.db.WithContext(ctx).First(&person, id).Error; err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// FindByEmail matches case-insensitively, ordered by ID so the oldest
+// record comes first.
+func (r *personRepository) FindByEmail(ctx context.Context, email string) ([]entity.Person, error) {
+	var persons []entity.Person
+	err := r.db.WithContext(ctx).Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).
+		Order("id").
+		Find(&persons).Error
+	return persons, err
+}
+
+func (r *personRepository) List(ctx context.Context) ([]entity.Person, error) {
+	var persons []entity.Person
+	if err := r.db.WithContext(ctx).Order("id").Find(&persons).Error; err != nil {
+		return nil, err
+	}
+	return persons, nil
+}
+
+func (r *personRepository) Update(ctx context.Context, person entity.Person) error {
+	return r.db.WithContext(ctx).Save(&person).Error
+}
+
+func (r *personRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&entity.Person{}, id).Error
+}
+
+func (r *personRepository) CountVideos(ctx context.Context, id uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.Video{}).Where("person_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// DuplicateEmails returns the normalized emails shared by more than one person.
+func (r *personRepository) DuplicateEmails(ctx context.Context) ([]string, error) {
+	var emails []string
+	err := r.db.WithContext(ctx).Model(&entity.Person{}).
+		Group("LOWER(email)").
+		Having("COUNT(*) > 1").
+		Order("LOWER(email)").
+		Pluck("LOWER(email)", &emails).Error
+	return emails, err
+}
+
+// Merge repoints every video of sourceID to targetID and removes sourceID.
+func (r *personRepository) Merge(ctx context.Context, targetID, sourceID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Video{}).
+			Where("person_id = ?", sourceID).
+			Update("person_id", targetID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Person{}, sourceID).Error
+	})
+}
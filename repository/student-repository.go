@@ -1,18 +1,30 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/Sarthak-D97/go_stuAPI/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
-	Create(student entity.Student) (entity.Student, error)
-	GetByID(id int64) (*entity.Student, error)
-	List() ([]entity.Student, error)
-	Update(id int64, student entity.Student) error
-	Delete(id int64) error
+	Create(ctx context.Context, student entity.Student) (entity.Student, error)
+	GetByID(ctx context.Context, id int64) (*entity.Student, error)
+	GetByEmail(ctx context.Context, email string) (*entity.Student, error)
+	GetByNumber(ctx context.Context, number string) (*entity.Student, error)
+	ListWithoutNumber(ctx context.Context) ([]entity.Student, error)
+	NextNumber(ctx context.Context, prefix string) (int64, error)
+	List(ctx context.Context) ([]entity.Student, error)
+	ListByStatus(ctx context.Context, statuses []string) ([]entity.Student, error)
+	Transition(ctx context.Context, transition *entity.StudentTransition) (bool, error)
+	Transitions(ctx context.Context, studentID int) ([]entity.StudentTransition, error)
+	Update(ctx context.Context, id int64, student entity.Student) error
+	Delete(ctx context.Context, id int64) error
+	HasAccount(ctx context.Context, id int) (bool, error)
+	Merge(ctx context.Context, survivor entity.Student, duplicateID int, transition *entity.StudentTransition) error
+	MergedInto(ctx context.Context, id int) (int, error)
 }
 
 type gormRepository struct {
@@ -23,16 +35,16 @@ func New(db *gorm.DB) Repository {
 	return &gormRepository{db: db}
 }
 
-func (r *gormRepository) Create(student entity.Student) (entity.Student, error) {
-	if err := r.db.Create(&student).Error; err != nil {
+func (r *gormRepository) Create(ctx context.Context, student entity.Student) (entity.Student, error) {
+	if err := r.db.WithContext(ctx).Create(&student).Error; err != nil {
 		return entity.Student{}, err
 	}
 	return student, nil
 }
 
-func (r *gormRepository) GetByID(id int64) (*entity.Student, error) {
+func (r *gormRepository) GetByID(ctx context.Context, id int64) (*entity.Student, error) {
 	var student entity.Student
-	if err := r.db.First(&student, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&student, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
@@ -41,19 +53,162 @@ func (r *gormRepository) GetByID(id int64) (*entity.Student, error) {
 	return &student, nil
 }
 
-func (r *gormRepository) List() ([]entity.Student, error) {
+func (r *gormRepository) GetByEmail(ctx context.Context, email string) (*entity.Student, error) {
+	var student entity.Student
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&student).Error; err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
+func (r *gormRepository) GetByNumber(ctx context.Context, number string) (*entity.Student, error) {
+	var student entity.Student
+	if err := r.db.WithContext(ctx).Where("student_number = ?", number).First(&student).Error; err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
+// ListWithoutNumber returns the students that have no student number yet,
+// ordered by ID.
+func (r *gormRepository) ListWithoutNumber(ctx context.Context) ([]entity.Student, error) {
 	var students []entity.Student
-	if err := r.db.Find(&students).Error; err != nil {
+	err := r.db.WithContext(ctx).Where("student_number = '' OR student_number IS NULL").Order("id").Find(&students).Error
+	return students, err
+}
+
+// NextNumber advances the counter of prefix and returns its new value,
+// starting at 1.
+func (r *gormRepository) NextNumber(ctx context.Context, prefix string) (int64, error) {
+	seq := entity.StudentNumberSequence{Prefix: prefix, Value: 1}
+	err := r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "prefix"}},
+			DoUpdates: clause.Assignments(map[string]any{"value": gorm.Expr("student_number_sequences.value + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "value"}}},
+	).Create(&seq).Error
+	return seq.Value, err
+}
+
+func (r *gormRepository) List(ctx context.Context) ([]entity.Student, error) {
+	var students []entity.Student
+	if err := r.db.WithContext(ctx).Find(&students).Error; err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
+func (r *gormRepository) ListByStatus(ctx context.Context, statuses []string) ([]entity.Student, error) {
+	var students []entity.Student
+	if err := r.db.WithContext(ctx).Where("enrollment_status IN ?", statuses).Find(&students).Error; err != nil {
 		return nil, err
 	}
 	return students, nil
 }
 
-func (r *gormRepository) Update(id int64, student entity.Student) error {
+// Transition moves the student to the transition's status and records it.
+// It reports false, and changes nothing, when the student is no longer in
+// the status the transition starts from.
+func (r *gormRepository) Transition(ctx context.Context, transition *entity.StudentTransition) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entity.Student{}).
+			Where("id = ? AND enrollment_status = ?", transition.StudentID, transition.FromStatus).
+			Update("enrollment_status", transition.ToStatus)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		applied = true
+		return tx.Create(transition).Error
+	})
+	return applied, err
+}
+
+// Transitions returns the student's status history, oldest first.
+func (r *gormRepository) Transitions(ctx context.Context, studentID int) ([]entity.StudentTransition, error) {
+	var transitions []entity.StudentTransition
+	err := r.db.WithContext(ctx).Where("student_id = ?", studentID).Order("created_at, id").Find(&transitions).Error
+	return transitions, err
+}
+
+func (r *gormRepository) Update(ctx context.Context, id int64, student entity.Student) error {
 	student.ID = int(id)
-	return r.db.Save(&student).Error
+	return r.db.WithContext(ctx).Save(&student).Error
+}
+
+// Delete removes the student together with its guardian links and status
+// history.
+func (r *gormRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("student_id = ?", id).Delete(&entity.StudentGuardian{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("student_id = ?", id).Delete(&entity.StudentTransition{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Student{}, id).Error
+	})
+}
+
+// HasAccount reports whether a portal account is linked to the student.
+func (r *gormRepository) HasAccount(ctx context.Context, id int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.User{}).Where("student_id = ?", id).Count(&count).Error
+	return count > 0, err
 }
 
-func (r *gormRepository) Delete(id int64) error {
-	return r.db.Delete(&entity.Student{}, id).Error
+// Merge saves survivor and hands it everything that belongs to the
+// duplicate: guardian links (the survivor's link wins where both have the
+// same guardian), the portal account and the status history. The
+// duplicate is deleted and its ID, and any ID that was redirected to it,
+// redirects to the survivor. transition, when set, records a status change
+// of the survivor.
+func (r *gormRepository) Merge(ctx context.Context, survivor entity.Student, duplicateID int, transition *entity.StudentTransition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("student_id = ? AND guardian_id IN (?)", duplicateID,
+			tx.Model(&entity.StudentGuardian{}).Select("guardian_id").Where("student_id = ?", survivor.ID)).
+			Delete(&entity.StudentGuardian{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.StudentGuardian{}).Where("student_id = ?", duplicateID).Update("student_id", survivor.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.User{}).Where("student_id = ?", duplicateID).Update("student_id", survivor.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.StudentTransition{}).Where("student_id = ?", duplicateID).Update("student_id", survivor.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.StudentRedirect{}).Where("to_id = ?", duplicateID).Update("to_id", survivor.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&entity.StudentRedirect{FromID: duplicateID, ToID: survivor.ID}).Error; err != nil {
+			return err
+		}
+
+		// The duplicate goes first, so the survivor can take its email or
+		// student number.
+		if err := tx.Delete(&entity.Student{}, duplicateID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&survivor).Error; err != nil {
+			return err
+		}
+		if transition != nil {
+			return tx.Create(transition).Error
+		}
+		return nil
+	})
+}
+
+// MergedInto returns the ID of the student that the student with this ID
+// was merged into.
+func (r *gormRepository) MergedInto(ctx context.Context, id int) (int, error) {
+	var redirect entity.StudentRedirect
+	if err := r.db.WithContext(ctx).Where("from_id = ?", id).First(&redirect).Error; err != nil {
+		return 0, err
+	}
+	return redirect.ToID, nil
 }
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaxonomyRepository interface {
+	ListTags(ctx context.Context) ([]entity.Tag, error)
+	// FindOrCreateTags returns the stored tags for names, inserting any that
+	// do not exist yet.
+	FindOrCreateTags(ctx context.Context, names []string) ([]entity.Tag, error)
+	CreateCategory(ctx context.Context, category entity.Category) (entity.Category, error)
+	GetCategory(ctx context.Context, id uint64) (*entity.Category, error)
+	ListCategories(ctx context.Context) ([]entity.Category, error)
+	DeleteCategory(ctx context.Context, id uint64) error
+	CountCategoryUsage(ctx context.Context, id uint64) (children int64, videos int64, err error)
+}
+
+type taxonomyRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxonomyRepository(db *gorm.DB) TaxonomyRepository {
+	return &taxonomyRepository{db: db}
+}
+
+func (r *taxonomyRepository) ListTags(ctx context.Context) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	err := r.db.WithContext(ctx).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *taxonomyRepository) FindOrCreateTags(ctx context.Context, names []string) ([]entity.Tag, error) {
+	if len(names) == 0 {
+		return []entity.Tag{}, nil
+	}
+
+	tags := make([]entity.Tag, len(names))
+	for i, name := range names {
+		tags[i] = entity.Tag{Name: name}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+		tags = tags[:0]
+		return tx.Where("name IN ?", names).Order("name").Find(&tags).Error
+	})
+	return tags, err
+}
+
+func (r *taxonomyRepository) CreateCategory(ctx context.Context, category entity.Category) (entity.Category, error) {
+	if err := r.db.WithContext(ctx).Create(&category).Error; err != nil {
+		return entity.Category{}, err
+	}
+	return category, nil
+}
+
+func (r *taxonomyRepository) GetCategory(ctx context.Context, id uint64) (*entity.Category, error) {
+	var category entity.Category
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *taxonomyRepository) ListCategories(ctx context.Context) ([]entity.Category, error) {
+	var categories []entity.Category
+	err := r.db.WithContext(ctx).Order("name").Find(&categories).Error
+	return categories, err
+}
+
+func (r *taxonomyRepository) DeleteCategory(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&entity.Category{}, id).Error
+}
+
+func (r *taxonomyRepository) CountCategoryUsage(ctx context.Context, id uint64) (int64, int64, error) {
+	var children, videos int64
+	if err := r.db.WithContext(ctx).Model(&entity.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := r.db.WithContext(ctx).Model(&entity.Video{}).Where("category_id = ?", id).Count(&videos).Error; err != nil {
+		return 0, 0, err
+	}
+	return children, videos, nil
+}
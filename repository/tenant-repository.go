@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+)
+
+type TenantRepository interface {
+	Create(ctx context.Context, tenant entity.Tenant) (entity.Tenant, error)
+	Update(ctx context.Context, tenant entity.Tenant) error
+	GetByID(ctx context.Context, id uint64) (*entity.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*entity.Tenant, error)
+	List(ctx context.Context) ([]entity.Tenant, error)
+}
+
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+func (r *tenantRepository) Create(ctx context.Context, tenant entity.Tenant) (entity.Tenant, error) {
+	if err := r.db.WithContext(ctx).Create(&tenant).Error; err != nil {
+		return entity.Tenant{}, err
+	}
+	return tenant, nil
+}
+
+func (r *tenantRepository) Update(ctx context.Context, tenant entity.Tenant) error {
+	return r.db.WithContext(ctx).Model(&tenant).Select("name", "settings").Updates(&tenant).Error
+}
+
+func (r *tenantRepository) GetByID(ctx context.Context, id uint64) (*entity.Tenant, error) {
+	var tenant entity.Tenant
+	if err := r.db.WithContext(ctx).First(&tenant, id).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *tenantRepository) GetBySlug(ctx context.Context, slug string) (*entity.Tenant, error) {
+	var tenant entity.Tenant
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *tenantRepository) List(ctx context.Context) ([]entity.Tenant, error) {
+	var tenants []entity.Tenant
+	err := r.db.WithContext(ctx).Order("slug").Find(&tenants).Error
+	return tenants, err
+}
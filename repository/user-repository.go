@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	FindByIdentity(ctx context.Context, issuer, subject string) (*entity.User, error)
+	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+	FindByID(ctx context.Context, id uint64) (*entity.User, error)
+	FindByStudent(ctx context.Context, studentID int) (*entity.User, error)
+	SetStudent(ctx context.Context, userID uint64, studentID *int, role string) error
+	FindByGuardian(ctx context.Context, guardianID uint64) (*entity.User, error)
+	SetGuardian(ctx context.Context, userID uint64, guardianID *uint64, role string) error
+	CreateWithIdentity(ctx context.Context, user entity.User, issuer, subject string) (entity.User, error)
+	RecordLogin(ctx context.Context, user entity.User) error
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) FindByIdentity(ctx context.Context, issuer, subject string) (*entity.User, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).
+		Joins("JOIN user_identities ON user_identities.user_id = users.id").
+		Where("user_identities.issuer = ? AND user_identities.subject = ?", issuer, subject).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint64) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByStudent(ctx context.Context, studentID int) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.WithContext(ctx).Where("student_id = ?", studentID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetStudent links the user to a student record, or unlinks it when
+// studentID is nil, and sets its role.
+func (r *userRepository) SetStudent(ctx context.Context, userID uint64, studentID *int, role string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{"student_id": studentID, "role": role}).Error
+}
+
+func (r *userRepository) FindByGuardian(ctx context.Context, guardianID uint64) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.WithContext(ctx).Where("guardian_id = ?", guardianID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetGuardian links the user to a guardian record, or unlinks it when
+// guardianID is nil, and sets its role.
+func (r *userRepository) SetGuardian(ctx context.Context, userID uint64, guardianID *uint64, role string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{"guardian_id": guardianID, "role": role}).Error
+}
+
+// CreateWithIdentity stores a new user together with its provider link.
+func (r *userRepository) CreateWithIdentity(ctx context.Context, user entity.User, issuer, subject string) (entity.User, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user.Identities = nil
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.UserIdentity{UserID: user.ID, Issuer: issuer, Subject: subject}).Error
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// RecordLogin saves the profile fields refreshed from the provider and
+// stamps the login time.
+func (r *userRepository) RecordLogin(ctx context.Context, user entity.User) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ?", user.ID).
+		Updates(map[string]any{
+			"email":         user.Email,
+			"name":          user.Name,
+			"role":          user.Role,
+			"last_login_at": time.Now(),
+		}).Error
+}
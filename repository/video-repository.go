@@ -1,34 +1,63 @@
 package repository
 
 import (
+	"context"
+	"strings"
+
 	"github.com/Sarthak-D97/go_stuAPI/entity"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type VideoRepository interface {
-	Save(video entity.Video) (entity.Video, error)
-	Update(video entity.Video) error
-	Delete(video entity.Video) error
-	FindAll() ([]entity.Video, error)
+	Save(ctx context.Context, video entity.Video) (entity.Video, error)
+	Update(ctx context.Context, video entity.Video) error
+	Delete(ctx context.Context, video entity.Video) error
+	FindAll(ctx context.Context) ([]entity.Video, error)
+	FindByID(ctx context.Context, id uint64) (*entity.Video, error)
+	// FindByURL returns the first video stored under any of urls.
+	FindByURL(ctx context.Context, urls ...string) (*entity.Video, error)
+	FindByAuthor(ctx context.Context, personID uint64) ([]entity.Video, error)
+	Search(ctx context.Context, filter VideoFilter) (VideoSearchResult, error)
 	CloseDB() error
 }
 
+// VideoFilter narrows a video search. Zero values disable a filter; all
+// enabled filters must match.
+type VideoFilter struct {
+	Query       string
+	Tags        []string
+	CategoryIDs []uint64
+	AuthorID    uint64
+	Page        int
+	PageSize    int
+}
+
+type FacetCount struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type VideoFacets struct {
+	Tags       []FacetCount `json:"tags"`
+	Categories []FacetCount `json:"categories"`
+	Authors    []FacetCount `json:"authors"`
+}
+
+type VideoSearchResult struct {
+	Items    []entity.Video `json:"items"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Facets   VideoFacets    `json:"facets"`
+}
+
 type database struct {
 	connection *gorm.DB
 }
 
-func NewVideoRepository() VideoRepository {
-	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
-	if err != nil {
-		panic("failed to start database Connection")
-	}
-	err = db.AutoMigrate(&entity.Video{}, &entity.Person{})
-	if err != nil {
-		panic("failed to migrate database")
-	}
-
+func NewVideoRepository(db *gorm.DB) VideoRepository {
 	return &database{
 		connection: db,
 	}
@@ -42,21 +71,153 @@ func (db *database) CloseDB() error {
 	return sqlDB.Close()
 }
 
-func (db *database) Save(video entity.Video) (entity.Video, error) {
-	err := db.connection.Create(&video).Error
+func (db *database) Save(ctx context.Context, video entity.Video) (entity.Video, error) {
+	err := db.connection.WithContext(ctx).Create(&video).Error
 	return video, err
 }
 
-func (db *database) Update(video entity.Video) error {
-	return db.connection.Save(&video).Error
+func (db *database) Update(ctx context.Context, video entity.Video) error {
+	return db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags", "RatingAverage", "RatingCount").Save(&video).Error; err != nil {
+			return err
+		}
+		return tx.Model(&video).Association("Tags").Replace(video.Tags)
+	})
+}
+
+// Delete removes the video together with its tag links, ratings and
+// comments.
+func (db *database) Delete(ctx context.Context, video entity.Video) error {
+	return db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&video).Association("Tags").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", video.ID).Delete(&entity.Rating{}).Error; err != nil {
+			return err
+		}
+		comments := tx.Model(&entity.Comment{}).Select("id").Where("video_id = ?", video.ID)
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&entity.CommentReport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", video.ID).Delete(&entity.Comment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&video).Error
+	})
+}
+
+func (db *database) FindAll(ctx context.Context) ([]entity.Video, error) {
+	var videos []entity.Video
+	err := db.connection.WithContext(ctx).Preload(clause.Associations).Find(&videos).Error
+	return videos, err
+}
+
+func (db *database) FindByID(ctx context.Context, id uint64) (*entity.Video, error) {
+	var video entity.Video
+	if err := db.connection.WithContext(ctx).Preload(clause.Associations).First(&video, id).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
 }
 
-func (db *database) Delete(video entity.Video) error {
-	return db.connection.Delete(&video).Error
+func (db *database) FindByURL(ctx context.Context, urls ...string) (*entity.Video, error) {
+	var video entity.Video
+	err := db.connection.WithContext(ctx).Preload(clause.Associations).Where("url IN ?", urls).Order("id").First(&video).Error
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
 }
 
-func (db *database) FindAll() ([]entity.Video, error) {
+func (db *database) FindByAuthor(ctx context.Context, personID uint64) ([]entity.Video, error) {
 	var videos []entity.Video
-	err := db.connection.Preload(clause.Associations).Find(&videos).Error
+	err := db.connection.WithContext(ctx).Preload(clause.Associations).Where("person_id = ?", personID).Find(&videos).Error
 	return videos, err
 }
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterScope applies every enabled filter of f to a query over videos.
+func filterScope(f VideoFilter) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		for _, term := range strings.Fields(f.Query) {
+			pattern := "%" + likeEscaper.Replace(term) + "%"
+			q = q.Where(`(videos.title LIKE ? ESCAPE '\' OR videos.description LIKE ? ESCAPE '\')`, pattern, pattern)
+		}
+		for _, tag := range f.Tags {
+			q = q.Where("videos.id IN (SELECT video_tags.video_id FROM video_tags JOIN tags ON tags.id = video_tags.tag_id WHERE tags.name = ?)", tag)
+		}
+		if len(f.CategoryIDs) > 0 {
+			q = q.Where("videos.category_id IN ?", f.CategoryIDs)
+		}
+		if f.AuthorID != 0 {
+			q = q.Where("videos.person_id = ?", f.AuthorID)
+		}
+		return q
+	}
+}
+
+// Search returns one page of matching videos together with tag, category
+// and author facet counts computed over the whole filtered set.
+func (db *database) Search(ctx context.Context, f VideoFilter) (VideoSearchResult, error) {
+	result := VideoSearchResult{
+		Items:    []entity.Video{},
+		Page:     f.Page,
+		PageSize: f.PageSize,
+		Facets: VideoFacets{
+			Tags:       []FacetCount{},
+			Categories: []FacetCount{},
+			Authors:    []FacetCount{},
+		},
+	}
+	matching := func() *gorm.DB {
+		return db.connection.WithContext(ctx).Model(&entity.Video{}).Scopes(filterScope(f))
+	}
+
+	if err := matching().Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	err := matching().
+		Preload(clause.Associations).
+		Order("videos.created_at DESC, videos.id DESC").
+		Limit(f.PageSize).
+		Offset((f.Page - 1) * f.PageSize).
+		Find(&result.Items).Error
+	if err != nil {
+		return result, err
+	}
+
+	ids := matching().Select("videos.id")
+
+	err = db.connection.WithContext(ctx).Table("tags").
+		Select("tags.id, tags.name, COUNT(*) AS count").
+		Joins("JOIN video_tags ON video_tags.tag_id = tags.id").
+		Where("video_tags.video_id IN (?)", ids).
+		Group("tags.id, tags.name").
+		Order("count DESC, tags.name").
+		Scan(&result.Facets.Tags).Error
+	if err != nil {
+		return result, err
+	}
+
+	err = db.connection.WithContext(ctx).Table("videos").
+		Select("categories.id, categories.name, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = videos.category_id").
+		Where("videos.id IN (?)", ids).
+		Group("categories.id, categories.name").
+		Order("count DESC, categories.name").
+		Scan(&result.Facets.Categories).Error
+	if err != nil {
+		return result, err
+	}
+
+	err = db.connection.WithContext(ctx).Table("videos").
+		Select("people.id, people.first_name || ' ' || people.last_name AS name, COUNT(*) AS count").
+		Joins("JOIN people ON people.id = videos.person_id").
+		Where("videos.id IN (?)", ids).
+		Group("people.id, people.first_name, people.last_name").
+		Order("count DESC, name").
+		Scan(&result.Facets.Authors).Error
+	return result, err
+}
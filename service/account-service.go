@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/mail"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Password length limits. bcrypt ignores everything past 72 bytes, so
+// longer passwords are refused rather than silently cut.
+const (
+	minPasswordLength = 10
+	maxPasswordBytes  = 72
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrWeakPassword     = fmt.Errorf("password must be at least %d characters and at most %d bytes", minPasswordLength, maxPasswordBytes)
+	ErrEmailNotVerified = errors.New("email address is not verified")
+)
+
+// dummyHash is compared against when a login names no registered user, so
+// unknown usernames take as long as wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Registration is a sign-up with email and password.
+type Registration struct {
+	Email    string
+	Password string
+	Name     string
+}
+
+// AccountService handles accounts registered with an email address and
+// password: sign-up with email verification and password reset. Tokens
+// are mailed through the outbox and stored only as hashes. None of the
+// methods tell the caller whether an address has an account; the mailbox
+// owner learns what happened instead.
+type AccountService interface {
+	Register(ctx context.Context, reg Registration) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	// CheckPassword returns the registered user with that username and
+	// password, ErrInvalidCredentials, or ErrEmailNotVerified when the
+	// password is right but the address has not been confirmed yet.
+	CheckPassword(ctx context.Context, username, password string) (*entity.User, error)
+	// Role returns the role of a stored user, or gorm.ErrRecordNotFound.
+	Role(ctx context.Context, username string) (string, error)
+}
+
+type accountService struct {
+	accounts  repository.AccountRepository
+	users     repository.UserRepository
+	students  repository.Repository
+	guardians repository.GuardianRepository
+	outbox    repository.OutboxRepository
+	renderer  *mail.Renderer
+	cfg       config.Accounts
+	baseURL   string
+}
+
+func NewAccountService(
+	accounts repository.AccountRepository,
+	users repository.UserRepository,
+	students repository.Repository,
+	guardians repository.GuardianRepository,
+	outbox repository.OutboxRepository,
+	renderer *mail.Renderer,
+	cfg config.Accounts,
+	baseURL string,
+) AccountService {
+	return &accountService{
+		accounts:  accounts,
+		users:     users,
+		students:  students,
+		guardians: guardians,
+		outbox:    outbox,
+		renderer:  renderer,
+		cfg:       cfg,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// emailData is what the email templates can use.
+type emailData struct {
+	Name      string
+	Link      string
+	ExpiresIn string
+}
+
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAccountToken returns a random token and its entity for userID.
+func newAccountToken(userID uint64, purpose string, ttl time.Duration) (string, entity.AccountToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", entity.AccountToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, entity.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		Hash:      hashAccountToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// humanDuration formats link lifetimes for emails, e.g. "24 hours".
+func humanDuration(d time.Duration) string {
+	unit, n := "minute", int(d.Round(time.Minute)/time.Minute)
+	if d >= time.Hour && d%time.Hour == 0 {
+		unit, n = "hour", int(d/time.Hour)
+	}
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// link points into the tenant of ctx, at the tenant's own base URL when
+// it has one.
+func (s *accountService) link(ctx context.Context, path, token string) string {
+	base := s.baseURL
+	if t := TenantFrom(ctx); t != nil && t.Settings.BaseURL != "" {
+		base = strings.TrimSuffix(t.Settings.BaseURL, "/")
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// email renders a template into an outbox entry.
+func (s *accountService) email(template, to string, data emailData) (entity.OutboxEmail, error) {
+	msg, err := s.renderer.Render(template, to, data)
+	if err != nil {
+		return entity.OutboxEmail{}, err
+	}
+	return entity.OutboxEmail{
+		Recipient: msg.To,
+		Template:  template,
+		Subject:   msg.Subject,
+		Text:      msg.Text,
+		HTML:      msg.HTML,
+	}, nil
+}
+
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength || len(password) > maxPasswordBytes {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	if err := validatePassword(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// findUser returns the user with that email address as username, or nil.
+func (s *accountService) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := s.users.FindByUsername(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// Register creates an unverified student account and mails the
+// verification link. When the address is taken, its owner is told
+// instead, and the caller gets the same answer either way.
+func (s *accountService) Register(ctx context.Context, reg Registration) error {
+	email := normalizeUsername(reg.Email)
+	// Hash before looking the address up, so both paths take as long.
+	hash, err := hashPassword(reg.Password)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.findUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		msg, err := s.email("account-exists", email, emailData{Name: existing.Name})
+		if err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("registration for existing account", slog.String("username", email))
+		return s.outbox.Enqueue(ctx, msg)
+	}
+
+	secret, token, err := newAccountToken(0, entity.TokenVerifyEmail, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(reg.Name)
+	msg, err := s.email("verify-email", email, emailData{
+		Name:      name,
+		Link:      s.link(ctx, "/auth/verify-email", secret),
+		ExpiresIn: humanDuration(s.cfg.VerifyTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	user, err := s.accounts.Register(ctx, entity.User{
+		Username:     email,
+		Email:        email,
+		Name:         name,
+		Role:         RoleStudent,
+		PasswordHash: hash,
+	}, token, msg)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("user registered", slog.Uint64("user_id", user.ID), slog.String("username", email))
+	return nil
+}
+
+// token returns the stored token if it is valid for purpose.
+func (s *accountService) token(ctx context.Context, secret, purpose string) (*entity.AccountToken, error) {
+	token, err := s.accounts.GetToken(ctx, hashAccountToken(secret))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.Purpose != purpose || token.UsedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	return token, nil
+}
+
+func (s *accountService) VerifyEmail(ctx context.Context, secret string) error {
+	token, err := s.token(ctx, secret, entity.TokenVerifyEmail)
+	if err != nil {
+		return err
+	}
+	if err := s.accounts.VerifyEmail(ctx, *token); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	logging.FromContext(ctx).Info("email verified", slog.Uint64("user_id", token.UserID))
+	s.linkRecord(ctx, token.UserID)
+	return nil
+}
+
+// linkRecord links a newly verified account to the tenant's student record
+// with the same email address, unless another account holds it already.
+// Failing that, it links the account to the only guardian with that email
+// address and makes it a guardian account. Staff can link accounts by
+// hand otherwise, so failures are only logged.
+func (s *accountService) linkRecord(ctx context.Context, userID uint64) {
+	err := func() error {
+		user, err := s.users.FindByID(ctx, userID)
+		if err != nil || user.Role != RoleStudent || user.StudentID != nil || user.GuardianID != nil {
+			return err
+		}
+		student, err := s.students.GetByEmail(ctx, user.Email)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.linkGuardian(ctx, user)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.users.FindByStudent(ctx, student.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := s.users.SetStudent(ctx, user.ID, &student.ID, RoleStudent); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("student account linked by email", slog.Uint64("user_id", user.ID), slog.Int("student_id", student.ID))
+		return nil
+	}()
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to link account", slog.Uint64("user_id", userID), "error", err)
+	}
+}
+
+func (s *accountService) linkGuardian(ctx context.Context, user *entity.User) error {
+	guardians, err := s.guardians.FindByEmail(ctx, user.Email)
+	if err != nil || len(guardians) != 1 {
+		return err
+	}
+	guardian := guardians[0]
+	if _, err := s.users.FindByGuardian(ctx, guardian.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := s.users.SetGuardian(ctx, user.ID, &guardian.ID, RoleGuardian); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("guardian account linked by email", slog.Uint64("user_id", user.ID), slog.Uint64("guardian_id", guardian.ID))
+	return nil
+}
+
+// issue mails a new token to user, replacing their open ones.
+func (s *accountService) issue(ctx context.Context, user *entity.User, purpose, template, path string, ttl time.Duration) error {
+	secret, token, err := newAccountToken(user.ID, purpose, ttl)
+	if err != nil {
+		return err
+	}
+	msg, err := s.email(template, user.Email, emailData{
+		Name:      user.Name,
+		Link:      s.link(ctx, path, secret),
+		ExpiresIn: humanDuration(ttl),
+	})
+	if err != nil {
+		return err
+	}
+	return s.accounts.IssueToken(ctx, token, msg)
+}
+
+// ResendVerification mails a fresh link to a registered, unverified user
+// and does nothing for any other address.
+func (s *accountService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.findUser(ctx, normalizeUsername(email))
+	if err != nil || user == nil || user.PasswordHash == "" || user.EmailVerifiedAt != nil {
+		return err
+	}
+	return s.issue(ctx, user, entity.TokenVerifyEmail, "verify-email", "/auth/verify-email", s.cfg.VerifyTTL)
+}
+
+// RequestPasswordReset mails a reset link to a registered user. Users who
+// sign in through the identity provider have no password here and are
+// left alone, like unknown addresses.
+func (s *accountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.findUser(ctx, normalizeUsername(email))
+	if err != nil || user == nil || user.PasswordHash == "" {
+		return err
+	}
+	if err := s.issue(ctx, user, entity.TokenPasswordReset, "password-reset", "/auth/reset-password", s.cfg.ResetTTL); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("password reset requested", slog.Uint64("user_id", user.ID))
+	return nil
+}
+
+func (s *accountService) ResetPassword(ctx context.Context, secret, password string) error {
+	token, err := s.token(ctx, secret, entity.TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	user, err := s.users.FindByID(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+	msg, err := s.email("password-changed", user.Email, emailData{Name: user.Name})
+	if err != nil {
+		return err
+	}
+	if err := s.accounts.ResetPassword(ctx, *token, hash, msg); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	logging.FromContext(ctx).Info("password reset", slog.Uint64("user_id", user.ID))
+	return nil
+}
+
+func (s *accountService) CheckPassword(ctx context.Context, username, password string) (*entity.User, error) {
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.PasswordHash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+	return user, nil
+}
+
+func (s *accountService) Role(ctx context.Context, username string) (string, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
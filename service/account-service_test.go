@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/mail"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+var tokenLink = regexp.MustCompile(`token=([A-Za-z0-9_%-]+)`)
+
+func newTestAccountService(t *testing.T, gdb *gorm.DB) AccountService {
+	t.Helper()
+	renderer, err := mail.NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAccountService(
+		repository.NewAccountRepository(gdb),
+		repository.NewUserRepository(gdb),
+		repository.New(gdb),
+		repository.NewGuardianRepository(gdb),
+		repository.NewOutboxRepository(gdb),
+		renderer,
+		config.Accounts{VerifyTTL: time.Hour, ResetTTL: time.Hour},
+		"https://example.com",
+	)
+}
+
+// lastToken returns the token in the newest queued email with template.
+func lastToken(t *testing.T, gdb *gorm.DB, template string) string {
+	t.Helper()
+	var email entity.OutboxEmail
+	if err := gdb.Where("template = ?", template).Order("id DESC").First(&email).Error; err != nil {
+		t.Fatal(err)
+	}
+	m := tokenLink.FindStringSubmatch(email.Text)
+	if m == nil {
+		t.Fatalf("no token link in %s email: %q", template, email.Text)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	accounts := newTestAccountService(t, gdb)
+	const email, password = "ada@example.com", "correct horse battery"
+
+	if err := accounts.Register(ctx, Registration{Email: " Ada@Example.com ", Password: password, Name: "Ada"}); err != nil {
+		t.Fatal(err)
+	}
+	first := lastToken(t, gdb, "verify-email")
+	if _, err := accounts.CheckPassword(ctx, email, password); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("CheckPassword before verifying: error = %v, want ErrEmailNotVerified", err)
+	}
+
+	// A resent link replaces the first one.
+	if err := accounts.ResendVerification(ctx, email); err != nil {
+		t.Fatal(err)
+	}
+	second := lastToken(t, gdb, "verify-email")
+	other := WithTenant(context.Background(), &entity.Tenant{ID: 2, Slug: "north"})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		token   string
+		wantErr error
+	}{
+		{"replaced link", ctx, first, ErrInvalidToken},
+		{"unknown token", ctx, "not-a-token", ErrInvalidToken},
+		{"other tenant", other, second, ErrInvalidToken},
+		{"newest link", ctx, second, nil},
+		{"link used twice", ctx, second, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if err := accounts.VerifyEmail(tt.ctx, tt.token); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if _, err := accounts.CheckPassword(ctx, email, password); err != nil {
+		t.Errorf("CheckPassword after verifying: %v", err)
+	}
+}
+
+func TestRegisterExistingEmail(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	accounts := newTestAccountService(t, gdb)
+	reg := Registration{Email: "ada@example.com", Password: "correct horse battery", Name: "Ada"}
+
+	if err := accounts.Register(ctx, reg); err != nil {
+		t.Fatal(err)
+	}
+	reg.Password = "another long password"
+	if err := accounts.Register(ctx, reg); err != nil {
+		t.Fatalf("second Register: %v", err)
+	}
+
+	var users, notices int64
+	if err := gdb.WithContext(ctx).Model(&entity.User{}).Count(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Model(&entity.OutboxEmail{}).Where("template = ?", "account-exists").Count(&notices).Error; err != nil {
+		t.Fatal(err)
+	}
+	if users != 1 || notices != 1 {
+		t.Errorf("got %d users and %d account-exists emails, want 1 and 1", users, notices)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	accounts := newTestAccountService(t, gdb)
+	const email, oldPassword, newPassword = "ada@example.com", "correct horse battery", "a brand new password"
+
+	if err := accounts.Register(ctx, Registration{Email: email, Password: oldPassword}); err != nil {
+		t.Fatal(err)
+	}
+	verify := lastToken(t, gdb, "verify-email")
+	if err := accounts.RequestPasswordReset(ctx, email); err != nil {
+		t.Fatal(err)
+	}
+	replaced := lastToken(t, gdb, "password-reset")
+	if err := accounts.RequestPasswordReset(ctx, email); err != nil {
+		t.Fatal(err)
+	}
+	reset := lastToken(t, gdb, "password-reset")
+
+	expired, token, err := newAccountToken(1, entity.TokenPasswordReset, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	create(t, gdb, ctx, &token)
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		wantErr  error
+	}{
+		{"verification token", verify, newPassword, ErrInvalidToken},
+		{"replaced link", replaced, newPassword, ErrInvalidToken},
+		{"expired link", expired, newPassword, ErrInvalidToken},
+		{"weak password", reset, "short", ErrWeakPassword},
+		{"newest link", reset, newPassword, nil},
+		{"link used twice", reset, "yet another password", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if err := accounts.ResetPassword(ctx, tt.token, tt.password); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// The reset verified the address, so the new password signs in.
+	if _, err := accounts.CheckPassword(ctx, email, oldPassword); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password: error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := accounts.CheckPassword(ctx, email, newPassword); err != nil {
+		t.Errorf("new password: %v", err)
+	}
+}
+
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	accounts := newTestAccountService(t, gdb)
+	create(t, gdb, ctx, &entity.User{Username: "sso@example.com", Email: "sso@example.com", Role: RoleStudent})
+
+	for _, email := range []string{"nobody@example.com", "sso@example.com"} {
+		if err := accounts.RequestPasswordReset(ctx, email); err != nil {
+			t.Errorf("RequestPasswordReset(%q): %v", email, err)
+		}
+	}
+	var emails int64
+	if err := gdb.Model(&entity.OutboxEmail{}).Count(&emails).Error; err != nil {
+		t.Fatal(err)
+	}
+	if emails != 0 {
+		t.Errorf("%d emails queued, want none", emails)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix marks our keys so secret scanners and people can tell
+	// them apart.
+	apiKeyPrefix = "sr_"
+	// lastUsedGranularity limits last-used writes to one per key and
+	// interval instead of one per request.
+	lastUsedGranularity = time.Minute
+)
+
+// APIKeyResources are the /api path segments a key can be scoped to.
+var APIKeyResources = []string{"students", "guardians", "videos", "comments", "tags", "categories", "persons"}
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrInvalidScope  = errors.New("invalid scope")
+	ErrAPIKeyRevoked = errors.New("API key is revoked")
+)
+
+// APIKeyRequest describes a key to create.
+type APIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyService manages API keys and authenticates requests made with
+// them. Scopes are "<resource>:read", "<resource>:write" (which includes
+// read) or "*"; keys never carry admin rights.
+type APIKeyService interface {
+	// Create returns the new key's secret, which is not stored and cannot
+	// be shown again.
+	Create(ctx context.Context, req APIKeyRequest, createdBy string) (string, entity.APIKey, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	// Rotate replaces a key with a new one with the same name, scopes and
+	// expiry. The old key keeps working for grace, or stops immediately
+	// when grace is zero.
+	Rotate(ctx context.Context, id uint64, grace time.Duration, rotatedBy string) (string, entity.APIKey, error)
+	Revoke(ctx context.Context, id uint64) error
+	Authenticate(ctx context.Context, secret, ip string) (*entity.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+// APIKeyAllows reports whether scopes grant access to resource. Routes
+// outside APIKeyResources, such as MFA enrollment, are never allowed.
+func APIKeyAllows(scopes []string, resource string, write bool) bool {
+	if !slices.Contains(APIKeyResources, resource) {
+		return false
+	}
+	for _, scope := range scopes {
+		if scope == "*" {
+			return true
+		}
+		res, action, _ := strings.Cut(scope, ":")
+		if res == resource && (action == "write" || !write) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope == "*" {
+			continue
+		}
+		res, action, _ := strings.Cut(scope, ":")
+		if !slices.Contains(APIKeyResources, res) || (action != "read" && action != "write") {
+			return fmt.Errorf("%w %q", ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIKey returns a key of the form sr_<prefix>_<secret> and its
+// lookup prefix.
+func newAPIKey() (string, string, error) {
+	b := make([]byte, 25)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+	prefix := apiKeyPrefix + s[:8]
+	return prefix + "_" + s[8:], prefix, nil
+}
+
+// withSecret generates a secret for key and fills in its prefix and hash.
+func withSecret(key entity.APIKey) (string, entity.APIKey, error) {
+	secret, prefix, err := newAPIKey()
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+	key.Prefix = prefix
+	key.Hash = hashAPIKey(secret)
+	return secret, key, nil
+}
+
+func (s *apiKeyService) Create(ctx context.Context, req APIKeyRequest, createdBy string) (string, entity.APIKey, error) {
+	if err := validateScopes(req.Scopes); err != nil {
+		return "", entity.APIKey{}, err
+	}
+	secret, key, err := withSecret(entity.APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Scopes:    req.Scopes,
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+	created, err := s.repo.Create(ctx, key)
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+
+	logging.FromContext(ctx).Info("api key created", slog.Uint64("api_key_id", created.ID), slog.String("prefix", created.Prefix))
+	return secret, created, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]entity.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *apiKeyService) Rotate(ctx context.Context, id uint64, grace time.Duration, rotatedBy string) (string, entity.APIKey, error) {
+	old, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+	if old.RevokedAt != nil {
+		return "", entity.APIKey{}, ErrAPIKeyRevoked
+	}
+
+	secret, replacement, err := withSecret(entity.APIKey{
+		Name:      old.Name,
+		Scopes:    old.Scopes,
+		CreatedBy: rotatedBy,
+		ExpiresAt: old.ExpiresAt,
+	})
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+
+	now := time.Now()
+	if grace > 0 {
+		until := now.Add(grace)
+		if old.ExpiresAt == nil || until.Before(*old.ExpiresAt) {
+			old.ExpiresAt = &until
+		}
+	} else {
+		old.RevokedAt = &now
+	}
+	created, err := s.repo.Rotate(ctx, *old, replacement)
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+
+	logging.FromContext(ctx).Info("api key rotated",
+		slog.Uint64("api_key_id", old.ID), slog.Uint64("replacement_id", created.ID), slog.Duration("grace", grace))
+	return secret, created, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id uint64) error {
+	if err := s.repo.Revoke(ctx, id); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("api key revoked", slog.Uint64("api_key_id", id))
+	return nil
+}
+
+// Authenticate looks the key up by its prefix and compares hashes in
+// constant time. Unknown, revoked and expired keys all fail the same way.
+func (s *apiKeyService) Authenticate(ctx context.Context, secret, ip string) (*entity.APIKey, error) {
+	secret = strings.TrimSpace(secret)
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	prefix, _, found := strings.Cut(secret[len(apiKeyPrefix):], "_")
+	if !found {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, apiKeyPrefix+prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(secret))) != 1 ||
+		key.RevokedAt != nil ||
+		(key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedGranularity {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, ip); err != nil {
+			logging.FromContext(ctx).Warn("failed to record api key use", "error", err)
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+)
+
+func TestAPIKeyAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		resource string
+		write    bool
+		want     bool
+	}{
+		{"read scope reads", []string{"students:read"}, "students", false, true},
+		{"read scope cannot write", []string{"students:read"}, "students", true, false},
+		{"write scope reads", []string{"students:write"}, "students", false, true},
+		{"write scope writes", []string{"students:write"}, "students", true, true},
+		{"other resource", []string{"students:write"}, "videos", false, false},
+		{"several scopes", []string{"students:read", "videos:write"}, "videos", true, true},
+		{"wildcard", []string{"*"}, "guardians", true, true},
+		{"wildcard stays out of admin routes", []string{"*"}, "admin", false, false},
+		{"wildcard stays out of MFA routes", []string{"*"}, "auth", true, false},
+		{"no scopes", nil, "students", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := APIKeyAllows(tt.scopes, tt.resource, tt.write); got != tt.want {
+				t.Errorf("APIKeyAllows(%v, %q, %v) = %v, want %v", tt.scopes, tt.resource, tt.write, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyCreateScopes(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	keys := NewAPIKeyService(repository.NewAPIKeyRepository(gdb))
+
+	tests := []struct {
+		scopes  []string
+		wantErr error
+	}{
+		{[]string{"students:read", "videos:write"}, nil},
+		{[]string{"*"}, nil},
+		{[]string{"students"}, ErrInvalidScope},
+		{[]string{"students:delete"}, ErrInvalidScope},
+		{[]string{"admin:read"}, ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		_, _, err := keys.Create(ctx, APIKeyRequest{Name: "sync", Scopes: tt.scopes}, "admin")
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Create with %v: error = %v, want %v", tt.scopes, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	keys := NewAPIKeyService(repository.NewAPIKeyRepository(gdb))
+	otherTenant := WithTenant(context.Background(), &entity.Tenant{ID: 2, Slug: "north"})
+
+	newKey := func(expiresAt *time.Time) (string, entity.APIKey) {
+		secret, key, err := keys.Create(ctx, APIKeyRequest{Name: "sync", Scopes: []string{"students:read"}, ExpiresAt: expiresAt}, "admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret, key
+	}
+	valid, _ := newKey(nil)
+	past := time.Now().Add(-time.Minute)
+	expired, _ := newKey(&past)
+	revoked, revokedKey := newKey(nil)
+	if err := keys.Revoke(ctx, revokedKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	graced, gracedKey := newKey(nil)
+	gracedNew, _, err := keys.Rotate(ctx, gracedKey.ID, time.Hour, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, rotatedKey := newKey(nil)
+	rotatedNew, _, err := keys.Rotate(ctx, rotatedKey.ID, 0, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		secret string
+		ok     bool
+	}{
+		{"valid key", ctx, valid, true},
+		{"surrounding spaces", ctx, " " + valid + " ", true},
+		{"wrong secret", ctx, valid[:len(valid)-1] + "x", false},
+		{"not a key", ctx, "Bearer abc", false},
+		{"no prefix separator", ctx, "sr_abc", false},
+		{"expired", ctx, expired, false},
+		{"revoked", ctx, revoked, false},
+		{"rotated with grace, old key", ctx, graced, true},
+		{"rotated with grace, new key", ctx, gracedNew, true},
+		{"rotated without grace, old key", ctx, rotated, false},
+		{"rotated without grace, new key", ctx, rotatedNew, true},
+		{"other tenant", otherTenant, valid, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.Authenticate(tt.ctx, tt.secret, "10.0.0.1")
+			if tt.ok {
+				if err != nil || key == nil {
+					t.Fatalf("Authenticate = %v, %v, want the key", key, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("Authenticate error = %v, want ErrInvalidAPIKey", err)
+			}
+		})
+	}
+}
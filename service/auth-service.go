@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/lockout"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+// Lock kinds, also used as the prefix of lockout store keys.
+const (
+	LockUser = "user"
+	LockIP   = "ip"
+)
+
+// Roles of users and tokens. Admins and users are staff; students only
+// see their own record and guardians the records of their students.
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user"
+	RoleStudent  = "student"
+	RoleGuardian = "guardian"
+)
+
+// RoleFor maps the admin claim of a token to a role, for tokens issued
+// before tokens carried a role claim.
+func RoleFor(admin bool) string {
+	if admin {
+		return RoleAdmin
+	}
+	return RoleUser
+}
+
+// IsStaff reports whether role may work on every record of its tenant.
+func IsStaff(role string) bool {
+	return role == RoleAdmin || role == RoleUser
+}
+
+// auditLimit caps how many audit entries one listing returns.
+const auditLimit = 500
+
+// ErrInvalidCredentials is the only failure a client learns about, whether
+// the username is unknown or the password is wrong.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LockedError is returned while the username or the client IP of an attempt
+// is locked out. The password is not checked in that case.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return "too many failed login attempts"
+}
+
+// LoginAttempt is one set of credentials together with where it came from.
+// Channel tells the JSON login ("api") and the admin UI ("admin") apart in
+// the audit log.
+type LoginAttempt struct {
+	Username  string
+	Password  string
+	IP        string
+	UserAgent string
+	Channel   string
+}
+
+// LoginResult is a successful step of a login.
+type LoginResult struct {
+	Username string
+	Role     string
+	// AMR lists how the user authenticated, for the token's amr claim.
+	AMR []string
+	// MFAPending means the password was right but the user has MFA
+	// enabled, so the login must be finished with VerifyMFA.
+	MFAPending bool
+	// MFASetupRequired means the role must use MFA but the user has not
+	// enrolled yet. Their token only reaches the enrollment endpoints.
+	MFASetupRequired bool
+}
+
+func (r LoginResult) Admin() bool {
+	return r.Role == RoleAdmin
+}
+
+// LoginLock is an active lockout as shown to admins.
+type LoginLock struct {
+	Kind              string  `json:"kind"`
+	Value             string  `json:"value"`
+	RetryAfterSeconds float64 `json:"retry_after_seconds"`
+}
+
+// AuthService checks login attempts against LoginService and the accounts
+// registered through AccountService and protects them against password
+// guessing: failures are counted per username and per client IP, answered
+// with growing delays and, past a threshold, locked out for a while. Every
+// attempt is written to the login audit log.
+type AuthService interface {
+	// Authenticate checks the password step of a login.
+	Authenticate(ctx context.Context, attempt LoginAttempt) (LoginResult, error)
+	// VerifyMFA finishes a login that Authenticate left pending with a
+	// TOTP or recovery code. attempt.Password is ignored.
+	VerifyMFA(ctx context.Context, attempt LoginAttempt, code string) (LoginResult, error)
+	// SessionRole returns the current role of a signed-in user, a
+	// LockedError while the username is locked out, or
+	// ErrInvalidCredentials once the user is gone.
+	SessionRole(ctx context.Context, username string) (string, error)
+	Locks(ctx context.Context) ([]LoginLock, error)
+	Unlock(ctx context.Context, kind, value string) error
+	Audit(ctx context.Context, filter repository.LoginAuditFilter) ([]entity.LoginAudit, error)
+}
+
+type authService struct {
+	loginService *LoginService
+	accounts     AccountService
+	mfaService   MFAService
+	store        lockout.Store
+	repo         repository.AuthRepository
+	cfg          config.Lockout
+	mfaRoles     []string
+}
+
+func NewAuthService(
+	loginService *LoginService,
+	accounts AccountService,
+	mfaService MFAService,
+	store lockout.Store,
+	repo repository.AuthRepository,
+	cfg config.Lockout,
+	mfaCfg config.MFA,
+) AuthService {
+	return &authService{
+		loginService: loginService,
+		accounts:     accounts,
+		mfaService:   mfaService,
+		store:        store,
+		repo:         repo,
+		cfg:          cfg,
+		mfaRoles:     mfaCfg.RequiredRoles,
+	}
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// lockKey names the counter of a username or IP within the tenant of ctx,
+// e.g. "tenant:3:user:admin". Usernames are only unique per tenant, and
+// each tenant's admins see and clear only their own locks.
+func lockKey(ctx context.Context, kind, value string) string {
+	return tenantKey(ctx, kind+":"+value)
+}
+
+// lockedFor returns the longest lock on the attempt's username or IP. The
+// store failing is logged and treated as no lock, so an outage does not
+// keep everyone out.
+func (s *authService) lockedFor(ctx context.Context, keys ...string) time.Duration {
+	var longest time.Duration
+	for _, key := range keys {
+		d, err := s.store.LockedFor(ctx, key)
+		if err != nil {
+			logging.FromContext(ctx).Warn("lockout store unavailable", "error", err)
+			continue
+		}
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// fail counts a failure against key and locks it once it reaches limit.
+func (s *authService) fail(ctx context.Context, key string, limit int) int {
+	n, err := s.store.Fail(ctx, key, s.cfg.Window)
+	if err != nil {
+		logging.FromContext(ctx).Warn("lockout store unavailable", "error", err)
+		return 0
+	}
+	if limit > 0 && n >= limit {
+		if err := s.store.Lock(ctx, key, s.cfg.LockDuration); err != nil {
+			logging.FromContext(ctx).Warn("lockout store unavailable", "error", err)
+			return n
+		}
+		logging.FromContext(ctx).Warn("login locked out", slog.String("key", key), slog.Int("failures", n))
+	}
+	return n
+}
+
+// delay is DelayBase doubled for every failure after the first, capped at
+// DelayMax.
+func (s *authService) delay(failures int) time.Duration {
+	if failures < 1 || s.cfg.DelayBase <= 0 {
+		return 0
+	}
+	d := s.cfg.DelayBase
+	for i := 1; i < failures && d < s.cfg.DelayMax; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.DelayMax)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+func (s *authService) audit(ctx context.Context, attempt LoginAttempt, username, reason string) {
+	err := s.repo.RecordLogin(ctx, entity.LoginAudit{
+		Username:  username,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Channel:   attempt.Channel,
+		Success:   reason == entity.LoginOK,
+		Reason:    reason,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to record login audit", "error", err)
+	}
+}
+
+// checkLocked refuses attempts while the username or IP is locked out.
+func (s *authService) checkLocked(ctx context.Context, attempt LoginAttempt, username string) error {
+	d := s.lockedFor(ctx, lockKey(ctx, LockUser, username), lockKey(ctx, LockIP, attempt.IP))
+	if d <= 0 {
+		return nil
+	}
+	// Attempts against a locked account still count for the IP, so
+	// spraying many usernames from one address gets it locked too.
+	s.fail(ctx, lockKey(ctx, LockIP, attempt.IP), s.cfg.IPMaxFailures)
+	s.audit(ctx, attempt, username, entity.LoginLocked)
+	logging.FromContext(ctx).Warn("login rejected, locked out",
+		slog.String("username", username), slog.String("ip", attempt.IP), slog.Duration("retry_after", d))
+	return &LockedError{RetryAfter: d}
+}
+
+// failed counts a failed attempt, records it and holds the response back
+// for the progressive delay.
+func (s *authService) failed(ctx context.Context, attempt LoginAttempt, username, reason string) {
+	failures := max(
+		s.fail(ctx, lockKey(ctx, LockUser, username), s.cfg.MaxFailures),
+		s.fail(ctx, lockKey(ctx, LockIP, attempt.IP), s.cfg.IPMaxFailures),
+	)
+	s.audit(ctx, attempt, username, reason)
+	logging.FromContext(ctx).Warn("login failed",
+		slog.String("username", username), slog.String("ip", attempt.IP),
+		slog.String("reason", reason), slog.Int("failures", failures))
+
+	sleep(ctx, s.delay(failures))
+}
+
+// succeeded resets the username's failures and records the login. Only
+// the username's counter is reset; a correct password does not vouch for
+// everything else sent from the same address.
+func (s *authService) succeeded(ctx context.Context, attempt LoginAttempt, username string) {
+	if err := s.store.Clear(ctx, lockKey(ctx, LockUser, username)); err != nil {
+		logging.FromContext(ctx).Warn("lockout store unavailable", "error", err)
+	}
+	s.audit(ctx, attempt, username, entity.LoginOK)
+	logging.FromContext(ctx).Info("login succeeded", slog.String("username", username), slog.String("ip", attempt.IP))
+}
+
+func (s *authService) mfaRequired(ctx context.Context, role string) bool {
+	return slices.Contains(MFARequiredRoles(ctx, s.mfaRoles), role)
+}
+
+// checkPassword tries the built-in registrar account, then the registered
+// users, and returns the role of the one that matched.
+func (s *authService) checkPassword(ctx context.Context, username, password string) (string, error) {
+	if s.loginService.Login(ctx, username, password) {
+		return s.loginService.Role(username), nil
+	}
+	user, err := s.accounts.CheckPassword(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// role returns the role of a user who already passed the password step.
+// A username that is neither registered in the tenant nor the built-in
+// account gets no role at all.
+func (s *authService) role(ctx context.Context, username string) (string, error) {
+	role, err := s.accounts.Role(ctx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !s.loginService.IsBuiltIn(ctx, username) {
+			return "", ErrInvalidCredentials
+		}
+		return s.loginService.Role(username), nil
+	}
+	return role, err
+}
+
+// Authenticate treats unknown usernames exactly like known ones, including
+// counting and locking them, so neither the response nor the lock state
+// tells an attacker which usernames exist.
+func (s *authService) Authenticate(ctx context.Context, attempt LoginAttempt) (LoginResult, error) {
+	username := normalizeUsername(attempt.Username)
+	if err := s.checkLocked(ctx, attempt, username); err != nil {
+		return LoginResult{}, err
+	}
+
+	role, err := s.checkPassword(ctx, username, attempt.Password)
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		s.failed(ctx, attempt, username, entity.LoginInvalidCredentials)
+		return LoginResult{}, ErrInvalidCredentials
+	case errors.Is(err, ErrEmailNotVerified):
+		// Only someone with the right password gets this far, so telling
+		// them the address needs confirming gives nothing away.
+		s.audit(ctx, attempt, username, entity.LoginUnverified)
+		return LoginResult{}, err
+	case err != nil:
+		return LoginResult{}, err
+	}
+
+	result := LoginResult{
+		Username: username,
+		Role:     role,
+		AMR:      []string{AMRPassword},
+	}
+	status, err := s.mfaService.Status(ctx, username)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if status.Enabled {
+		// The failure counter stays until the code is right, so the
+		// password cannot be used to reset it between guesses.
+		result.MFAPending = true
+		result.AMR = nil
+		s.audit(ctx, attempt, username, entity.LoginMFAChallenge)
+		return result, nil
+	}
+	result.MFASetupRequired = s.mfaRequired(ctx, result.Role)
+
+	s.succeeded(ctx, attempt, username)
+	return result, nil
+}
+
+func (s *authService) VerifyMFA(ctx context.Context, attempt LoginAttempt, code string) (LoginResult, error) {
+	username := normalizeUsername(attempt.Username)
+	if err := s.checkLocked(ctx, attempt, username); err != nil {
+		return LoginResult{}, err
+	}
+
+	amr, err := s.mfaService.Verify(ctx, username, code)
+	if errors.Is(err, ErrInvalidMFACode) {
+		s.failed(ctx, attempt, username, entity.LoginInvalidMFA)
+		return LoginResult{}, err
+	}
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	role, err := s.role(ctx, username)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	s.succeeded(ctx, attempt, username)
+	return LoginResult{
+		Username: username,
+		Role:     role,
+		AMR:      amr,
+	}, nil
+}
+
+// SessionRole lets a long-lived session follow changes made after the
+// login: a demoted, removed or locked out user loses it on the next
+// request. Locks on the IP are left to the login form.
+func (s *authService) SessionRole(ctx context.Context, username string) (string, error) {
+	username = normalizeUsername(username)
+	if d := s.lockedFor(ctx, lockKey(ctx, LockUser, username)); d > 0 {
+		return "", &LockedError{RetryAfter: d}
+	}
+	return s.role(ctx, username)
+}
+
+// Locks lists the active lockouts of the tenant of ctx, longest remaining
+// first.
+func (s *authService) Locks(ctx context.Context) ([]LoginLock, error) {
+	locks, err := s.store.Locks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := tenantKey(ctx, "")
+	result := make([]LoginLock, 0, len(locks))
+	for key, d := range locks {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		kind, value, _ := strings.Cut(rest, ":")
+		result = append(result, LoginLock{Kind: kind, Value: value, RetryAfterSeconds: d.Seconds()})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RetryAfterSeconds > result[j].RetryAfterSeconds
+	})
+	return result, nil
+}
+
+// Unlock lifts the lock on a username or IP and resets its failure count.
+func (s *authService) Unlock(ctx context.Context, kind, value string) error {
+	if kind == LockUser {
+		value = normalizeUsername(value)
+	}
+	if err := s.store.Clear(ctx, lockKey(ctx, kind, value)); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("login lock cleared", slog.String("kind", kind), slog.String("value", value))
+	return nil
+}
+
+func (s *authService) Audit(ctx context.Context, filter repository.LoginAuditFilter) ([]entity.LoginAudit, error) {
+	filter.Username = normalizeUsername(filter.Username)
+	if filter.Limit <= 0 || filter.Limit > auditLimit {
+		filter.Limit = auditLimit
+	}
+	return s.repo.LoginAudit(ctx, filter)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/lockout"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	defaults := config.Lockout{DelayBase: 250 * time.Millisecond, DelayMax: 5 * time.Second}
+
+	tests := []struct {
+		name     string
+		cfg      config.Lockout
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", defaults, 0, 0},
+		{"first failure", defaults, 1, 250 * time.Millisecond},
+		{"second failure", defaults, 2, 500 * time.Millisecond},
+		{"third failure", defaults, 3, time.Second},
+		{"fifth failure", defaults, 5, 4 * time.Second},
+		{"capped", defaults, 6, 5 * time.Second},
+		{"many failures", defaults, 1000, 5 * time.Second},
+		{"disabled", config.Lockout{DelayMax: 5 * time.Second}, 3, 0},
+		{"base above max", config.Lockout{DelayBase: 10 * time.Second, DelayMax: 5 * time.Second}, 1, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &authService{cfg: tt.cfg}
+			if got := s.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockKey(t *testing.T) {
+	one := WithTenant(context.Background(), &entity.Tenant{ID: 1})
+	two := WithTenant(context.Background(), &entity.Tenant{ID: 2})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"tenant one", one, "tenant:1:user:ada"},
+		{"tenant two", two, "tenant:2:user:ada"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockKey(tt.ctx, "user", "ada"); got != tt.want {
+				t.Errorf("lockKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionRole(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	create(t, gdb, ctx,
+		&entity.User{Username: "ada", Role: RoleAdmin},
+		&entity.User{Username: "grace", Role: RoleUser},
+		&entity.User{Username: "locked", Role: RoleAdmin},
+	)
+	store := lockout.NewMemory()
+	if err := store.Lock(ctx, lockKey(ctx, LockUser, "locked"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	s := &authService{
+		loginService: NewLoginService("main"),
+		accounts:     newTestAccountService(t, gdb),
+		store:        store,
+	}
+	other := WithTenant(context.Background(), &entity.Tenant{ID: 2, Slug: "north"})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		username string
+		want     string
+		wantErr  error
+	}{
+		{"admin", ctx, "Ada", RoleAdmin, nil},
+		{"demoted", ctx, "grace", RoleUser, nil},
+		{"built-in admin", ctx, "admin", RoleAdmin, nil},
+		{"built-in admin in another tenant", other, "admin", "", ErrInvalidCredentials},
+		{"removed", ctx, "ghost", "", ErrInvalidCredentials},
+		{"other tenant", other, "ada", "", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SessionRole(tt.ctx, tt.username)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("SessionRole = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	var locked *LockedError
+	if _, err := s.SessionRole(ctx, "locked"); !errors.As(err, &locked) {
+		t.Errorf("SessionRole of a locked user: error = %v, want LockedError", err)
+	}
+}
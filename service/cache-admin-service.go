@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownCacheFamily = errors.New("unknown cache key family")
+
+// cacheFamilyPrefixes maps each flushable family to the prefix of its keys,
+// version keys included.
+var cacheFamilyPrefixes = map[string]string{
+	cacheFamilyStudent:     studentKeyPrefix,
+	cacheFamilyStudentList: studentListKey,
+}
+
+// CacheEntry describes one cached key. For a versioned family such as
+// "student:42", Version and DataKey show where the current value lives.
+type CacheEntry struct {
+	Key        string          `json:"key"`
+	Version    string          `json:"version,omitempty"`
+	DataKey    string          `json:"data_key,omitempty"`
+	TTLSeconds float64         `json:"ttl_seconds"`
+	Value      json.RawMessage `json:"value"`
+}
+
+// WarmResult reports the outcome of pre-warming a list of student IDs.
+type WarmResult struct {
+	Warmed  []uint          `json:"warmed"`
+	Missing []uint          `json:"missing"`
+	Failed  map[uint]string `json:"failed"`
+}
+
+type CacheAdminService interface {
+	Stats() map[string]CacheCounters
+	Inspect(ctx context.Context, key string) (*CacheEntry, error)
+	Flush(ctx context.Context, family string) (int, error)
+	Warm(ctx context.Context, ids []uint) WarmResult
+}
+
+type cacheAdminService struct {
+	cache          cache.Cache
+	stats          *CacheStats
+	studentService StudentService
+}
+
+func NewCacheAdminService(c cache.Cache, stats *CacheStats, studentService StudentService) CacheAdminService {
+	return &cacheAdminService{
+		cache:          c,
+		stats:          stats,
+		studentService: studentService,
+	}
+}
+
+func (s *cacheAdminService) Stats() map[string]CacheCounters {
+	return s.stats.Snapshot()
+}
+
+// Inspect resolves key through its version when it names a versioned family,
+// and otherwise reads it as a raw key. Keys are looked up within the
+// caller's tenant, so "student:42" reads "tenant:<id>:student:42".
+func (s *cacheAdminService) Inspect(ctx context.Context, key string) (*CacheEntry, error) {
+	key = tenantKey(ctx, key)
+	entry := &CacheEntry{Key: key}
+
+	dataKeyName := key
+	if version, err := s.cache.Get(ctx, versionKey(key)); err == nil {
+		entry.Version = string(version)
+		entry.DataKey = dataKey(key, entry.Version)
+		dataKeyName = entry.DataKey
+	}
+
+	value, err := s.cache.Get(ctx, dataKeyName)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := s.cache.TTL(ctx, dataKeyName)
+	if err != nil {
+		return nil, err
+	}
+	entry.TTLSeconds = ttl.Seconds()
+
+	if json.Valid(value) {
+		entry.Value = value
+	} else {
+		entry.Value, _ = json.Marshal(string(value))
+	}
+	return entry, nil
+}
+
+// Flush removes every key of family in the caller's tenant. Loads already
+// in flight write under the removed version and are never read back.
+func (s *cacheAdminService) Flush(ctx context.Context, family string) (int, error) {
+	prefix, ok := cacheFamilyPrefixes[family]
+	if !ok {
+		return 0, ErrUnknownCacheFamily
+	}
+
+	n, err := s.cache.DeletePrefix(ctx, tenantKey(ctx, prefix))
+	if err != nil {
+		return n, err
+	}
+
+	logging.FromContext(ctx).Info("cache family flushed", slog.String("family", family), slog.Int("keys", n))
+	return n, nil
+}
+
+// Warm loads each student through the cache so later reads are hits.
+func (s *cacheAdminService) Warm(ctx context.Context, ids []uint) WarmResult {
+	result := WarmResult{
+		Warmed:  []uint{},
+		Missing: []uint{},
+		Failed:  map[uint]string{},
+	}
+	for _, id := range ids {
+		_, err := s.studentService.FindByID(ctx, id)
+		switch {
+		case err == nil:
+			result.Warmed = append(result.Warmed, id)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			result.Missing = append(result.Missing, id)
+		default:
+			result.Failed[id] = err.Error()
+		}
+	}
+
+	logging.FromContext(ctx).Info("student cache warmed", slog.Int("warmed", len(result.Warmed)), slog.Int("requested", len(ids)))
+	return result
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Key families tracked by CacheStats.
+const (
+	cacheFamilyStudent     = "student"
+	cacheFamilyStudentList = "students_list"
+)
+
+// CacheCounters are the cache outcomes recorded for one key family. A fill
+// is a value written after a miss; FillErrors counts failed writes.
+type CacheCounters struct {
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Fills      int64 `json:"fills"`
+	FillErrors int64 `json:"fill_errors"`
+}
+
+type familyCounters struct {
+	hits, misses, fills, fillErrors atomic.Int64
+}
+
+// CacheStats collects per-family cache counters. It is safe for concurrent
+// use.
+type CacheStats struct {
+	mu       sync.RWMutex
+	families map[string]*familyCounters
+}
+
+func NewCacheStats() *CacheStats {
+	return &CacheStats{families: make(map[string]*familyCounters)}
+}
+
+func (s *CacheStats) family(name string) *familyCounters {
+	s.mu.RLock()
+	f, ok := s.families[name]
+	s.mu.RUnlock()
+	if ok {
+		return f
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok = s.families[name]; !ok {
+		f = &familyCounters{}
+		s.families[name] = f
+	}
+	return f
+}
+
+func (s *CacheStats) hit(family string)       { s.family(family).hits.Add(1) }
+func (s *CacheStats) miss(family string)      { s.family(family).misses.Add(1) }
+func (s *CacheStats) fill(family string)      { s.family(family).fills.Add(1) }
+func (s *CacheStats) fillError(family string) { s.family(family).fillErrors.Add(1) }
+
+// Snapshot returns the current counters of every family seen so far.
+func (s *CacheStats) Snapshot() map[string]CacheCounters {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]CacheCounters, len(s.families))
+	for name, f := range s.families {
+		out[name] = CacheCounters{
+			Hits:       f.hits.Load(),
+			Misses:     f.misses.Load(),
+			Fills:      f.fills.Load(),
+			FillErrors: f.fillErrors.Load(),
+		}
+	}
+	return out
+}
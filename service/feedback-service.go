@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+)
+
+const (
+	commentEditWindow   = 15 * time.Minute
+	commentDeleteWindow = 24 * time.Hour
+	// reportThreshold is the number of distinct reports after which a
+	// comment is hidden until a moderator reviews it.
+	reportThreshold = 3
+)
+
+var (
+	ErrNotCommentAuthor   = errors.New("only the author can change this comment")
+	ErrEditWindowClosed   = errors.New("the edit window for this comment has closed")
+	ErrDeleteWindowClosed = errors.New("the delete window for this comment has closed")
+	ErrInvalidParent      = errors.New("parent comment does not belong to this video")
+	ErrCommentDeleted     = errors.New("comment has been deleted")
+	ErrAlreadyReported    = errors.New("comment already reported by this student")
+)
+
+// RatingSummary is the aggregate rating of a video.
+type RatingSummary struct {
+	VideoID      uint64        `json:"video_id"`
+	Average      float64       `json:"average"`
+	Count        int64         `json:"count"`
+	Distribution map[int]int64 `json:"distribution"`
+}
+
+type FeedbackService interface {
+	Rate(ctx context.Context, rating entity.Rating) (RatingSummary, error)
+	Ratings(ctx context.Context, videoID uint64) (RatingSummary, error)
+
+	Comment(ctx context.Context, comment *entity.Comment) error
+	Thread(ctx context.Context, videoID uint64) ([]entity.Comment, error)
+	EditComment(ctx context.Context, id, studentID uint64, body string) (*entity.Comment, error)
+	DeleteComment(ctx context.Context, id, studentID uint64) error
+	Report(ctx context.Context, report entity.CommentReport) error
+
+	ModerationQueue(ctx context.Context) ([]entity.Comment, error)
+	Approve(ctx context.Context, id uint64) error
+	Hide(ctx context.Context, id uint64) error
+
+	// MoveStudent gives a merged student's ratings, comments and reports
+	// to the student it was merged into.
+	MoveStudent(ctx context.Context, from, into int) error
+}
+
+type feedbackService struct {
+	repo           repository.FeedbackRepository
+	videoService   VideoService
+	studentService StudentService
+}
+
+func NewFeedbackService(repo repository.FeedbackRepository, videoService VideoService, studentService StudentService) FeedbackService {
+	return &feedbackService{
+		repo:           repo,
+		videoService:   videoService,
+		studentService: studentService,
+	}
+}
+
+// checkParticipants makes sure both the video and the student exist. They
+// live in different databases, so this cannot be a foreign key.
+func (s *feedbackService) checkParticipants(ctx context.Context, videoID, studentID uint64) error {
+	if _, err := s.videoService.FindByID(ctx, videoID); err != nil {
+		return err
+	}
+	_, err := s.studentService.FindByID(ctx, uint(studentID))
+	return err
+}
+
+func (s *feedbackService) Rate(ctx context.Context, rating entity.Rating) (RatingSummary, error) {
+	if err := s.checkParticipants(ctx, rating.VideoID, rating.StudentID); err != nil {
+		return RatingSummary{}, err
+	}
+
+	rating.ID = 0
+	if err := s.repo.UpsertRating(ctx, rating); err != nil {
+		return RatingSummary{}, err
+	}
+
+	logging.FromContext(ctx).Info("video rated",
+		slog.Uint64("video_id", rating.VideoID),
+		slog.Uint64("student_id", rating.StudentID),
+		slog.Int("score", rating.Score),
+	)
+	return s.Ratings(ctx, rating.VideoID)
+}
+
+func (s *feedbackService) Ratings(ctx context.Context, videoID uint64) (RatingSummary, error) {
+	video, err := s.videoService.FindByID(ctx, videoID)
+	if err != nil {
+		return RatingSummary{}, err
+	}
+	distribution, err := s.repo.RatingDistribution(ctx, videoID)
+	if err != nil {
+		return RatingSummary{}, err
+	}
+	return RatingSummary{
+		VideoID:      videoID,
+		Average:      video.RatingAverage,
+		Count:        video.RatingCount,
+		Distribution: distribution,
+	}, nil
+}
+
+func (s *feedbackService) Comment(ctx context.Context, comment *entity.Comment) error {
+	if err := s.checkParticipants(ctx, comment.VideoID, comment.StudentID); err != nil {
+		return err
+	}
+	if comment.ParentID != nil {
+		parent, err := s.repo.GetComment(ctx, *comment.ParentID)
+		if err != nil || parent.VideoID != comment.VideoID {
+			return ErrInvalidParent
+		}
+	}
+
+	comment.ID = 0
+	comment.Status = entity.CommentVisible
+	comment.Deleted = false
+	comment.ReportCount = 0
+	comment.ModeratedAt = nil
+	comment.EditedAt = nil
+
+	created, err := s.repo.CreateComment(ctx, *comment)
+	if err != nil {
+		return err
+	}
+	*comment = created
+
+	logging.FromContext(ctx).Info("comment created", slog.Uint64("comment_id", created.ID), slog.Uint64("video_id", created.VideoID))
+	return nil
+}
+
+// Thread returns the visible comments of a video as a tree. Replies under a
+// hidden comment are dropped along with it.
+func (s *feedbackService) Thread(ctx context.Context, videoID uint64) ([]entity.Comment, error) {
+	if _, err := s.videoService.FindByID(ctx, videoID); err != nil {
+		return nil, err
+	}
+	comments, err := s.repo.ListComments(ctx, videoID, entity.CommentVisible)
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make(map[uint64][]entity.Comment)
+	roots := []entity.Comment{}
+	for _, c := range comments {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			replies[*c.ParentID] = append(replies[*c.ParentID], c)
+		}
+	}
+
+	var attach func(nodes []entity.Comment) []entity.Comment
+	attach = func(nodes []entity.Comment) []entity.Comment {
+		for i := range nodes {
+			nodes[i].Replies = attach(replies[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots), nil
+}
+
+// ownComment loads a comment and checks that studentID wrote it and that it
+// is still within window.
+func (s *feedbackService) ownComment(ctx context.Context, id, studentID uint64, window time.Duration, closed error) (*entity.Comment, error) {
+	comment, err := s.repo.GetComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Deleted {
+		return nil, ErrCommentDeleted
+	}
+	if comment.StudentID != studentID {
+		return nil, ErrNotCommentAuthor
+	}
+	if time.Since(comment.CreatedAt) > window {
+		return nil, closed
+	}
+	return comment, nil
+}
+
+func (s *feedbackService) EditComment(ctx context.Context, id, studentID uint64, body string) (*entity.Comment, error) {
+	comment, err := s.ownComment(ctx, id, studentID, commentEditWindow, ErrEditWindowClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = &now
+	if err := s.repo.UpdateComment(ctx, *comment); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("comment edited", slog.Uint64("comment_id", id))
+	return comment, nil
+}
+
+// DeleteComment blanks the comment instead of removing the row so replies
+// keep their place in the thread.
+func (s *feedbackService) DeleteComment(ctx context.Context, id, studentID uint64) error {
+	comment, err := s.ownComment(ctx, id, studentID, commentDeleteWindow, ErrDeleteWindowClosed)
+	if err != nil {
+		return err
+	}
+
+	comment.Body = ""
+	comment.Deleted = true
+	if err := s.repo.UpdateComment(ctx, *comment); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("comment deleted", slog.Uint64("comment_id", id))
+	return nil
+}
+
+func (s *feedbackService) Report(ctx context.Context, report entity.CommentReport) error {
+	comment, err := s.repo.GetComment(ctx, report.CommentID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.studentService.FindByID(ctx, uint(report.StudentID)); err != nil {
+		return err
+	}
+
+	report.ID = 0
+	count, created, err := s.repo.AddReport(ctx, report)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrAlreadyReported
+	}
+
+	logging.FromContext(ctx).Info("comment reported", slog.Uint64("comment_id", comment.ID), slog.Int("report_count", count))
+
+	// Comments a moderator already approved stay up; others are held for review.
+	if count >= reportThreshold && comment.Status == entity.CommentVisible && comment.ModeratedAt == nil {
+		return s.repo.SetCommentStatus(ctx, comment.ID, entity.CommentPending, nil)
+	}
+	return nil
+}
+
+func (s *feedbackService) ModerationQueue(ctx context.Context) ([]entity.Comment, error) {
+	return s.repo.ModerationQueue(ctx)
+}
+
+func (s *feedbackService) moderate(ctx context.Context, id uint64, status entity.CommentStatus) error {
+	if _, err := s.repo.GetComment(ctx, id); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.repo.SetCommentStatus(ctx, id, status, &now); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("comment moderated", slog.Uint64("comment_id", id), slog.String("status", string(status)))
+	return nil
+}
+
+func (s *feedbackService) Approve(ctx context.Context, id uint64) error {
+	return s.moderate(ctx, id, entity.CommentVisible)
+}
+
+func (s *feedbackService) Hide(ctx context.Context, id uint64) error {
+	return s.moderate(ctx, id, entity.CommentHidden)
+}
+
+func (s *feedbackService) MoveStudent(ctx context.Context, from, into int) error {
+	if err := s.repo.MoveStudent(ctx, uint64(from), uint64(into)); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("student feedback moved", slog.Int("from", from), slog.Int("into", into))
+	return nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGuardianContact = errors.New("a guardian needs an email address or a phone number")
+	ErrGuardianPhone   = errors.New("a phone number is required to be contacted by sms or phone")
+	ErrGuardianLinked  = errors.New("guardian is already linked to another account")
+	ErrAccountLinked   = errors.New("account is already linked to a student or guardian record")
+)
+
+// GuardianService manages guardians, their links to students and their
+// portal accounts. A guardian account sees the students it is linked to
+// and nothing else.
+type GuardianService interface {
+	Create(ctx context.Context, guardian *entity.Guardian) error
+	FindByID(ctx context.Context, id uint64) (*entity.Guardian, error)
+	FindAll(ctx context.Context) ([]entity.Guardian, error)
+	Update(ctx context.Context, guardian *entity.Guardian) error
+	Delete(ctx context.Context, id uint64) error
+	// ForStudent returns the student's guardians in contact order.
+	ForStudent(ctx context.Context, studentID int) ([]entity.StudentGuardian, error)
+	Students(ctx context.Context, guardianID uint64) ([]entity.Student, error)
+	// Link links the guardian to the student, or updates the details of
+	// their link. A zero Priority puts the guardian after the others.
+	Link(ctx context.Context, link *entity.StudentGuardian) error
+	Unlink(ctx context.Context, studentID int, guardianID uint64) error
+	LinkAccount(ctx context.Context, guardianID uint64, username string) error
+	UnlinkAccount(ctx context.Context, guardianID uint64) error
+}
+
+type guardianService struct {
+	guardians repository.GuardianRepository
+	students  repository.Repository
+	users     repository.UserRepository
+}
+
+func NewGuardianService(guardians repository.GuardianRepository, students repository.Repository, users repository.UserRepository) GuardianService {
+	return &guardianService{
+		guardians: guardians,
+		students:  students,
+		users:     users,
+	}
+}
+
+// validateGuardian normalizes the contact details and checks the guardian
+// can be reached the way they prefer.
+func validateGuardian(guardian *entity.Guardian) error {
+	guardian.Email = normalizeEmail(guardian.Email)
+	if guardian.PreferredChannel == "" {
+		guardian.PreferredChannel = entity.ChannelEmail
+	}
+	if guardian.Email == "" && guardian.Phone == "" {
+		return ErrGuardianContact
+	}
+	if guardian.PreferredChannel != entity.ChannelEmail && guardian.Phone == "" {
+		return ErrGuardianPhone
+	}
+	return nil
+}
+
+func (s *guardianService) Create(ctx context.Context, guardian *entity.Guardian) error {
+	if err := validateGuardian(guardian); err != nil {
+		return err
+	}
+
+	created, err := s.guardians.Create(ctx, *guardian)
+	if err != nil {
+		return err
+	}
+	*guardian = created
+
+	logging.FromContext(ctx).Info("guardian created successfully", slog.Uint64("guardian_id", created.ID))
+	return nil
+}
+
+func (s *guardianService) FindByID(ctx context.Context, id uint64) (*entity.Guardian, error) {
+	return s.guardians.GetByID(ctx, id)
+}
+
+func (s *guardianService) FindAll(ctx context.Context) ([]entity.Guardian, error) {
+	return s.guardians.List(ctx)
+}
+
+func (s *guardianService) Update(ctx context.Context, guardian *entity.Guardian) error {
+	existing, err := s.guardians.GetByID(ctx, guardian.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateGuardian(guardian); err != nil {
+		return err
+	}
+	guardian.CreatedAt = existing.CreatedAt
+
+	if err := s.guardians.Update(ctx, *guardian); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("guardian updated successfully", slog.Uint64("guardian_id", guardian.ID))
+	return nil
+}
+
+func (s *guardianService) Delete(ctx context.Context, id uint64) error {
+	if _, err := s.guardians.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.guardians.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("guardian deleted successfully", slog.Uint64("guardian_id", id))
+	return nil
+}
+
+func (s *guardianService) ForStudent(ctx context.Context, studentID int) ([]entity.StudentGuardian, error) {
+	if _, err := s.students.GetByID(ctx, int64(studentID)); err != nil {
+		return nil, err
+	}
+	return s.guardians.ForStudent(ctx, studentID)
+}
+
+func (s *guardianService) Students(ctx context.Context, guardianID uint64) ([]entity.Student, error) {
+	if _, err := s.guardians.GetByID(ctx, guardianID); err != nil {
+		return nil, err
+	}
+	return s.guardians.Students(ctx, guardianID)
+}
+
+func (s *guardianService) Link(ctx context.Context, link *entity.StudentGuardian) error {
+	if _, err := s.students.GetByID(ctx, int64(link.StudentID)); err != nil {
+		return err
+	}
+	guardian, err := s.guardians.GetByID(ctx, link.GuardianID)
+	if err != nil {
+		return err
+	}
+
+	if link.Priority == 0 {
+		links, err := s.guardians.ForStudent(ctx, link.StudentID)
+		if err != nil {
+			return err
+		}
+		for _, l := range links {
+			if l.GuardianID == link.GuardianID {
+				link.Priority = l.Priority
+			}
+		}
+		if link.Priority == 0 {
+			if link.Priority, err = s.guardians.NextPriority(ctx, link.StudentID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.guardians.Link(ctx, *link); err != nil {
+		return err
+	}
+	link.Guardian = guardian
+
+	logging.FromContext(ctx).Info("guardian linked to student",
+		slog.Uint64("guardian_id", link.GuardianID),
+		slog.Int("student_id", link.StudentID),
+	)
+	return nil
+}
+
+func (s *guardianService) Unlink(ctx context.Context, studentID int, guardianID uint64) error {
+	if err := s.guardians.Unlink(ctx, studentID, guardianID); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("guardian unlinked from student",
+		slog.Uint64("guardian_id", guardianID),
+		slog.Int("student_id", studentID),
+	)
+	return nil
+}
+
+// LinkAccount makes the registered account with that username the
+// guardian's portal account and gives it the guardian role.
+func (s *guardianService) LinkAccount(ctx context.Context, guardianID uint64, username string) error {
+	if _, err := s.guardians.GetByID(ctx, guardianID); err != nil {
+		return err
+	}
+	user, err := s.users.FindByUsername(ctx, normalizeUsername(username))
+	if err != nil {
+		return err
+	}
+	if IsStaff(user.Role) {
+		return ErrStaffNotLinkable
+	}
+	if user.StudentID != nil || (user.GuardianID != nil && *user.GuardianID != guardianID) {
+		return ErrAccountLinked
+	}
+	if linked, err := s.users.FindByGuardian(ctx, guardianID); err == nil {
+		if linked.ID == user.ID {
+			return nil
+		}
+		return ErrGuardianLinked
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := s.users.SetGuardian(ctx, user.ID, &guardianID, RoleGuardian); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("guardian account linked", slog.Uint64("user_id", user.ID), slog.Uint64("guardian_id", guardianID))
+	return nil
+}
+
+// UnlinkAccount detaches the guardian's portal account. The account keeps
+// the guardian role, so it does not gain any other access.
+func (s *guardianService) UnlinkAccount(ctx context.Context, guardianID uint64) error {
+	user, err := s.users.FindByGuardian(ctx, guardianID)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetGuardian(ctx, user.ID, nil, RoleGuardian); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("guardian account unlinked", slog.Uint64("user_id", user.ID), slog.Uint64("guardian_id", guardianID))
+	return nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+)
+
+func TestGuardianAccess(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	mother, stranger := uint64(1), uint64(2)
+	create(t, gdb, ctx,
+		&entity.Student{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com"},
+		&entity.Student{ID: 2, Name: "Byron Lovelace", Email: "byron@example.com"},
+		&entity.Student{ID: 3, Name: "Grace Hopper", Email: "grace@example.com"},
+		&entity.Guardian{ID: mother, Name: "Anne Lovelace"},
+		&entity.Guardian{ID: stranger, Name: "Mary Hopper"},
+		&entity.StudentGuardian{StudentID: 1, GuardianID: mother, Relationship: "mother", Priority: 1},
+		&entity.StudentGuardian{StudentID: 2, GuardianID: mother, Relationship: "mother", Priority: 1},
+		&entity.User{Username: "anne", Role: RoleGuardian, GuardianID: &mother},
+		&entity.User{Username: "unlinked", Role: RoleGuardian},
+	)
+	profiles := newTestProfileService(t, gdb)
+
+	tests := []struct {
+		name     string
+		username string
+		student  int
+		want     bool
+	}{
+		{"guardian sees a linked student", "anne", 1, true},
+		{"guardian sees every linked student", "anne", 2, true},
+		{"guardian cannot see another student", "anne", 3, false},
+		{"unlinked guardian account sees nothing", "unlinked", 1, false},
+		{"unknown guardian account sees nothing", "ghost", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := profiles.CanView(ctx, tt.username, RoleGuardian, tt.student)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("CanView(%q, %d) = %v, want %v", tt.username, tt.student, got, tt.want)
+			}
+		})
+	}
+
+	profile, err := profiles.Me(ctx, "anne", RoleGuardian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Guardian == nil || profile.Guardian.ID != mother {
+		t.Fatalf("Me guardian = %+v, want guardian %d", profile.Guardian, mother)
+	}
+	var ids []int
+	for _, s := range profile.Students {
+		ids = append(ids, s.ID)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []int{1, 2}) {
+		t.Errorf("Me students = %v, want 1 and 2", ids)
+	}
+
+	// Only guardian accounts get the guardian view, whatever they link to.
+	create(t, gdb, ctx, &entity.User{Username: "clerk", Role: RoleUser, GuardianID: &stranger})
+	if profile, err := profiles.Me(ctx, "clerk", RoleUser); err != nil || profile.Guardian != nil || len(profile.Students) != 0 {
+		t.Errorf("Me for staff = %+v, %v, want no guardian record", profile, err)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -8,26 +11,53 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// mfaChallengeTTL is how long a user has to enter their code after the
+// password was accepted.
+const mfaChallengeTTL = 5 * time.Minute
+
 type JWTService interface {
-	GenerateToken(username string, admin bool) string
+	// GenerateToken issues an access token for a user with role, valid in
+	// the tenant with slug tenant. amr lists the methods the user
+	// authenticated with, e.g. "pwd", "otp", "mfa".
+	GenerateToken(username, role, tenant string, amr ...string) string
 	ValidateToken(token string) (*jwt.Token, error)
+	// GenerateMFAChallenge issues a short-lived token proving the password
+	// step of a login succeeded. It is signed with a separate key, so it
+	// is never accepted as an access token. It names the tenant the
+	// password was checked in, so the login can only finish there.
+	GenerateMFAChallenge(username, tenant string) string
+	// ValidateMFAChallenge returns the username and tenant of a challenge.
+	ValidateMFAChallenge(token string) (string, string, error)
 }
 
 type jwtCustomClaims struct {
-	Username string `json:"username"`
-	Admin    bool   `json:"admin"`
+	Username string   `json:"username"`
+	Admin    bool     `json:"admin"`
+	Role     string   `json:"role,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	Tenant   string   `json:"tenant,omitempty"`
+	jwt.StandardClaims
+}
+
+type mfaChallengeClaims struct {
+	Tenant string `json:"tenant,omitempty"`
 	jwt.StandardClaims
 }
 
 type jwtService struct {
-	secretKey string
-	issuer    string
+	secretKey    string
+	challengeKey []byte
+	issuer       string
 }
 
 func NewJWTService() JWTService {
+	secret := GetSecretKey()
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("mfa-challenge"))
 	return &jwtService{
-		secretKey: GetSecretKey(),
-		issuer:    "sarthak-d97",
+		secretKey:    secret,
+		challengeKey: mac.Sum(nil),
+		issuer:       "sarthak-d97",
 	}
 }
 func GetSecretKey() string {
@@ -38,10 +68,13 @@ func GetSecretKey() string {
 	return secret
 }
 
-func (j *jwtService) GenerateToken(username string, admin bool) string {
+func (j *jwtService) GenerateToken(username, role, tenant string, amr ...string) string {
 	claims := &jwtCustomClaims{
 		username,
-		admin,
+		role == RoleAdmin,
+		role,
+		amr,
+		tenant,
 		jwt.StandardClaims{
 			Issuer:    j.issuer,
 			IssuedAt:  time.Now().Unix(),
@@ -65,3 +98,40 @@ func (j *jwtService) ValidateToken(tokenString string) (*jwt.Token, error) {
 		return []byte(j.secretKey), nil
 	})
 }
+
+func (j *jwtService) GenerateMFAChallenge(username, tenant string) string {
+	claims := &mfaChallengeClaims{
+		Tenant: tenant,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   username,
+			Issuer:    j.issuer,
+			Audience:  "mfa-challenge",
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(mfaChallengeTTL).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t, err := token.SignedString(j.challengeKey)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (j *jwtService) ValidateMFAChallenge(tokenString string) (string, string, error) {
+	var claims mfaChallengeClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return j.challengeKey, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if !claims.VerifyAudience("mfa-challenge", true) || claims.Subject == "" {
+		return "", "", errors.New("not an MFA challenge token")
+	}
+	return claims.Subject, claims.Tenant, nil
+}
@@ -1,17 +1,48 @@
 package service
 
+import (
+	"context"
+	"crypto/subtle"
+)
+
 type LoginService struct {
 	authorizedUser string
 	password       string
+	defaultTenant  string
 }
 
-func NewLoginService() *LoginService {
+// NewLoginService creates the built-in registrar account. It belongs to
+// the tenant with the slug defaultTenant and cannot log in to any other.
+func NewLoginService(defaultTenant string) *LoginService {
 	return &LoginService{
 		authorizedUser: "admin",
 		password:       "password",
+		defaultTenant:  defaultTenant,
 	}
 }
 
-func (ls *LoginService) Login(user, pass string) bool {
-	return ls.authorizedUser == user && ls.password == pass
+// inDefaultTenant reports whether ctx is scoped to the default tenant.
+func (ls *LoginService) inDefaultTenant(ctx context.Context) bool {
+	t := TenantFrom(ctx)
+	return t != nil && t.Slug == ls.defaultTenant
+}
+
+// Login compares both fields in constant time, so response timing does not
+// reveal whether the username was right.
+func (ls *LoginService) Login(ctx context.Context, user, pass string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(ls.authorizedUser), []byte(user))
+	passOK := subtle.ConstantTimeCompare([]byte(ls.password), []byte(pass))
+	return userOK&passOK == 1 && ls.inDefaultTenant(ctx)
+}
+
+// IsBuiltIn reports whether user names the built-in account in the tenant
+// of ctx.
+func (ls *LoginService) IsBuiltIn(ctx context.Context, user string) bool {
+	return subtle.ConstantTimeCompare([]byte(ls.authorizedUser), []byte(user)) == 1 && ls.inDefaultTenant(ctx)
+}
+
+// Role returns the role of an authenticated user. The built-in account is
+// the registrar, an admin.
+func (ls *LoginService) Role(user string) string {
+	return RoleAdmin
 }
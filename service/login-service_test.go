@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+)
+
+func TestBuiltInLogin(t *testing.T) {
+	ls := NewLoginService("main")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		user     string
+		password string
+		want     bool
+		builtIn  bool
+	}{
+		{"default tenant", WithTenant(context.Background(), &entity.Tenant{ID: 1, Slug: "main"}), "admin", "password", true, true},
+		{"wrong password", WithTenant(context.Background(), &entity.Tenant{ID: 1, Slug: "main"}), "admin", "guess", false, true},
+		{"other tenant", WithTenant(context.Background(), &entity.Tenant{ID: 2, Slug: "north"}), "admin", "password", false, false},
+		{"no tenant", context.Background(), "admin", "password", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ls.Login(tt.ctx, tt.user, tt.password); got != tt.want {
+				t.Errorf("Login = %v, want %v", got, tt.want)
+			}
+			if got := ls.IsBuiltIn(tt.ctx, tt.user); got != tt.builtIn {
+				t.Errorf("IsBuiltIn = %v, want %v", got, tt.builtIn)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/mail"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+)
+
+// Retry pacing of the outbox: the first retry comes after retryBase, each
+// later one after twice the previous pause, up to retryMax. A claimed
+// email is left alone for claimLease while it is being sent.
+const (
+	retryBase  = 30 * time.Second
+	retryMax   = time.Hour
+	claimLease = 2 * time.Minute
+)
+
+// MailDispatcher delivers the emails queued in the outbox.
+type MailDispatcher interface {
+	// Run dispatches due emails every poll interval until ctx is done.
+	Run(ctx context.Context)
+	// Dispatch tries one batch of due emails and returns how many it
+	// picked up.
+	Dispatch(ctx context.Context) (int, error)
+}
+
+type mailDispatcher struct {
+	repo   repository.OutboxRepository
+	sender mail.Sender
+	cfg    config.Mail
+	logger *slog.Logger
+}
+
+func NewMailDispatcher(repo repository.OutboxRepository, sender mail.Sender, cfg config.Mail, logger *slog.Logger) MailDispatcher {
+	return &mailDispatcher{
+		repo:   repo,
+		sender: sender,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// retryDelay is the pause after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempts && d < retryMax; i++ {
+		d *= 2
+	}
+	return min(d, retryMax)
+}
+
+func (d *mailDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Keep sending while full batches come back, so a backlog drains
+		// without waiting a poll interval per batch.
+		for {
+			n, err := d.Dispatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					d.logger.Error("mail outbox unavailable", "error", err)
+				}
+				break
+			}
+			if n < d.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *mailDispatcher) Dispatch(ctx context.Context) (int, error) {
+	emails, err := d.repo.Claim(ctx, d.cfg.BatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+	for _, email := range emails {
+		d.send(ctx, email)
+	}
+	return len(emails), nil
+}
+
+// send delivers one email and records the outcome.
+func (d *mailDispatcher) send(ctx context.Context, email entity.OutboxEmail) {
+	logger := d.logger.With(slog.Uint64("email_id", email.ID), slog.String("template", email.Template))
+	err := d.sender.Send(ctx, mail.Message{
+		To:      email.Recipient,
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	})
+	if err == nil {
+		if err := d.repo.MarkSent(ctx, email.ID); err != nil {
+			logger.Error("failed to mark email sent", "error", err)
+		}
+		logger.Info("email sent")
+		return
+	}
+
+	var retryAt *time.Time
+	if email.Attempts < d.cfg.MaxAttempts {
+		at := time.Now().Add(retryDelay(email.Attempts))
+		retryAt = &at
+		logger.Warn("email delivery failed, will retry",
+			slog.Int("attempts", email.Attempts), slog.Time("retry_at", at), "error", err)
+	} else {
+		logger.Error("email delivery failed, giving up", slog.Int("attempts", email.Attempts), "error", err)
+	}
+	if err := d.repo.MarkFailed(ctx, email.ID, err.Error(), retryAt); err != nil {
+		logger.Error("failed to record email failure", "error", err)
+	}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/totp"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew accepts codes from one step either side of now.
+	totpSkew = 1
+)
+
+// Authentication method references (RFC 8176) put in the amr claim.
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+	ErrMFANotEnrolled    = errors.New("MFA enrollment not started")
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+)
+
+// MFASetup is what a user needs to add the account to an
+// authenticator app. URI is usually rendered as a QR code.
+type MFASetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFAStatus describes a user's MFA setup.
+type MFAStatus struct {
+	Enabled           bool       `json:"enabled"`
+	ConfirmedAt       *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+// MFAService manages TOTP enrollment and checks second factors.
+type MFAService interface {
+	Status(ctx context.Context, username string) (MFAStatus, error)
+	Enroll(ctx context.Context, username string) (MFASetup, error)
+	// Confirm activates the enrollment once code proves the app is set up
+	// and returns the recovery codes, which are only shown this once.
+	Confirm(ctx context.Context, username, code string) ([]string, error)
+	// Verify accepts a TOTP code or a recovery code and returns the amr
+	// values describing how the user authenticated.
+	Verify(ctx context.Context, username, code string) ([]string, error)
+	RegenerateRecoveryCodes(ctx context.Context, username string) ([]string, error)
+	Disable(ctx context.Context, username string) error
+}
+
+type mfaService struct {
+	repo   repository.MFARepository
+	issuer string
+}
+
+func NewMFAService(repo repository.MFARepository, issuer string) MFAService {
+	return &mfaService{
+		repo:   repo,
+		issuer: issuer,
+	}
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx together with
+// their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+		codes[i] = s[:5] + "-" + s[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed
+// loosely. The codes carry 50 random bits, so a plain SHA-256 is enough.
+func hashRecoveryCode(code string) string {
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// enrollment returns the user's enrollment, or nil when there is none.
+func (s *mfaService) enrollment(ctx context.Context, username string) (*entity.MFAEnrollment, error) {
+	enrollment, err := s.repo.GetEnrollment(ctx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return enrollment, err
+}
+
+func (s *mfaService) Status(ctx context.Context, username string) (MFAStatus, error) {
+	enrollment, err := s.enrollment(ctx, username)
+	if err != nil || enrollment == nil || enrollment.ConfirmedAt == nil {
+		return MFAStatus{}, err
+	}
+	left, err := s.repo.CountRecoveryCodes(ctx, username)
+	if err != nil {
+		return MFAStatus{}, err
+	}
+	return MFAStatus{Enabled: true, ConfirmedAt: enrollment.ConfirmedAt, RecoveryCodesLeft: left}, nil
+}
+
+// Enroll starts over with a new secret unless MFA is already active.
+func (s *mfaService) Enroll(ctx context.Context, username string) (MFASetup, error) {
+	existing, err := s.enrollment(ctx, username)
+	if err != nil {
+		return MFASetup{}, err
+	}
+	if existing != nil && existing.ConfirmedAt != nil {
+		return MFASetup{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return MFASetup{}, err
+	}
+	if err := s.repo.SaveEnrollment(ctx, entity.MFAEnrollment{Username: username, Secret: secret}); err != nil {
+		return MFASetup{}, err
+	}
+
+	logging.FromContext(ctx).Info("mfa enrollment started", slog.String("username", username))
+	return MFASetup{Secret: secret, URI: totp.URI(s.issuer, username, secret)}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, username, code string) ([]string, error) {
+	enrollment, err := s.enrollment(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if enrollment.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	step, ok := totp.Validate(enrollment.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Confirm(ctx, username, step, hashes); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("mfa enabled", slog.String("username", username))
+	return codes, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, username, code string) ([]string, error) {
+	enrollment, err := s.enrollment(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil || enrollment.ConfirmedAt == nil {
+		return nil, ErrInvalidMFACode
+	}
+
+	if step, ok := totp.Validate(enrollment.Secret, code, time.Now(), totpSkew); ok {
+		fresh, err := s.repo.UseStep(ctx, username, step)
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return nil, ErrInvalidMFACode
+		}
+		return []string{AMRPassword, AMROTP, AMRMFA}, nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, username, hashRecoveryCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidMFACode
+	}
+	logging.FromContext(ctx).Warn("mfa recovery code used", slog.String("username", username))
+	return []string{AMRPassword, AMRMFA}, nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, username string) ([]string, error) {
+	status, err := s.Status(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Enabled {
+		return nil, ErrMFANotEnrolled
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, username, hashes); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("mfa recovery codes regenerated", slog.String("username", username))
+	return codes, nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, username string) error {
+	if err := s.repo.Delete(ctx, username); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("mfa disabled", slog.String("username", username))
+	return nil
+}
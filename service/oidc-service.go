@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/config"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/oidc"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCState         = errors.New("login state mismatch")
+	ErrOIDCNoRole        = errors.New("account is not allowed to sign in")
+	ErrOIDCUsernameTaken = errors.New("username is already linked to another account")
+)
+
+// OIDCState ties a callback to the browser that started the login. It is
+// kept in a signed cookie between the two requests.
+type OIDCState struct {
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+}
+
+// OIDCService signs users in through the school's identity provider,
+// creating a local user on their first login.
+type OIDCService interface {
+	// Begin returns the provider URL to redirect to and the state to keep.
+	Begin(ctx context.Context) (string, OIDCState, error)
+	// Complete exchanges the callback's code and returns the signed in
+	// user. returnedState is the state query parameter of the callback.
+	Complete(ctx context.Context, saved OIDCState, returnedState, code string, attempt LoginAttempt) (LoginResult, error)
+}
+
+type oidcService struct {
+	client *oidc.Client
+	users  repository.UserRepository
+	audits repository.AuthRepository
+	cfg    config.OIDC
+}
+
+func NewOIDCService(client *oidc.Client, users repository.UserRepository, audits repository.AuthRepository, cfg config.OIDC) OIDCService {
+	return &oidcService{
+		client: client,
+		users:  users,
+		audits: audits,
+		cfg:    cfg,
+	}
+}
+
+func (s *oidcService) Begin(ctx context.Context) (string, OIDCState, error) {
+	state := OIDCState{
+		State:    oidc.RandomString(),
+		Nonce:    oidc.RandomString(),
+		Verifier: oidc.RandomString(),
+	}
+	redirect, err := s.client.AuthCodeURL(ctx, state.State, state.Nonce, oidc.Challenge(state.Verifier))
+	if err != nil {
+		return "", OIDCState{}, err
+	}
+	return redirect, state, nil
+}
+
+// roleRank orders the roles a provider group can grant.
+var roleRank = map[string]int{RoleStudent: 1, RoleUser: 2, RoleAdmin: 3}
+
+// role maps the user's groups to a local role; admin wins over user, user
+// over student.
+func (s *oidcService) role(groups []string) string {
+	role := ""
+	for _, group := range groups {
+		if r := s.cfg.GroupRoles[group]; roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	if role == "" {
+		return s.cfg.DefaultRole
+	}
+	return role
+}
+
+// username derives the local username. It always contains "@", so it can
+// never collide with the built-in password account.
+func (s *oidcService) username(identity *oidc.Identity) string {
+	if identity.Email != "" {
+		return strings.ToLower(identity.Email)
+	}
+	name := identity.PreferredUsername
+	if name == "" {
+		name = identity.Subject
+	}
+	host := identity.Issuer
+	if u, err := url.Parse(identity.Issuer); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.ToLower(name + "@" + host)
+}
+
+func (s *oidcService) audit(ctx context.Context, attempt LoginAttempt, username, reason string) {
+	err := s.audits.RecordLogin(ctx, entity.LoginAudit{
+		Username:  username,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Channel:   "oidc",
+		Success:   reason == entity.LoginOK,
+		Reason:    reason,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to record login audit", "error", err)
+	}
+}
+
+// provision finds the user linked to the identity or creates one.
+func (s *oidcService) provision(ctx context.Context, identity *oidc.Identity, role string) (*entity.User, error) {
+	user, err := s.users.FindByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		user.Email = identity.Email
+		user.Name = identity.Name
+		user.Role = role
+		return user, s.users.RecordLogin(ctx, *user)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	username := s.username(identity)
+	if _, err := s.users.FindByUsername(ctx, username); err == nil {
+		return nil, ErrOIDCUsernameTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	created, err := s.users.CreateWithIdentity(ctx, entity.User{
+		Username: username,
+		Email:    identity.Email,
+		Name:     identity.Name,
+		Role:     role,
+	}, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.RecordLogin(ctx, created); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("user provisioned from identity provider",
+		slog.String("username", created.Username), slog.String("role", role))
+	return &created, nil
+}
+
+func (s *oidcService) Complete(ctx context.Context, saved OIDCState, returnedState, code string, attempt LoginAttempt) (LoginResult, error) {
+	if saved.State == "" || subtle.ConstantTimeCompare([]byte(saved.State), []byte(returnedState)) != 1 {
+		return LoginResult{}, ErrOIDCState
+	}
+
+	identity, err := s.client.Exchange(ctx, code, saved.Verifier, saved.Nonce)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	username := s.username(identity)
+
+	role := s.role(identity.Groups)
+	if role == "" {
+		s.audit(ctx, attempt, username, entity.LoginNoRole)
+		logging.FromContext(ctx).Warn("identity provider login without a mapped group",
+			slog.String("username", username), slog.Any("groups", identity.Groups))
+		return LoginResult{}, ErrOIDCNoRole
+	}
+
+	user, err := s.provision(ctx, identity, role)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	s.audit(ctx, attempt, user.Username, entity.LoginOK)
+	logging.FromContext(ctx).Info("login succeeded", slog.String("username", user.Username), slog.String("ip", attempt.IP))
+
+	return LoginResult{
+		Username: user.Username,
+		Role:     role,
+		AMR:      identity.AMR,
+	}, nil
+}
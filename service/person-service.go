@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+)
+
+var (
+	ErrDuplicateEmail  = errors.New("a person with this email already exists")
+	ErrPersonHasVideos = errors.New("person still has videos; merge or reassign them first")
+	ErrSelfMerge       = errors.New("cannot merge a person into itself")
+)
+
+type PersonService interface {
+	Create(ctx context.Context, person *entity.Person) error
+	FindByID(ctx context.Context, id uint64) (*entity.Person, error)
+	FindAll(ctx context.Context) ([]entity.Person, error)
+	Update(ctx context.Context, person *entity.Person) error
+	Delete(ctx context.Context, id uint64) error
+	Videos(ctx context.Context, id uint64) ([]entity.Video, error)
+	Duplicates(ctx context.Context) (map[string][]entity.Person, error)
+	Merge(ctx context.Context, targetID, sourceID uint64) (*entity.Person, error)
+	// Resolve returns the stored person a video author refers to, either by
+	// ID or by matching email, creating one if neither exists.
+	Resolve(ctx context.Context, personID uint64, author *entity.Person) (*entity.Person, error)
+}
+
+type personService struct {
+	personRepository repository.PersonRepository
+	videoRepository  repository.VideoRepository
+}
+
+func NewPersonService(personRepo repository.PersonRepository, videoRepo repository.VideoRepository) PersonService {
+	return &personService{
+		personRepository: personRepo,
+		videoRepository:  videoRepo,
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *personService) Create(ctx context.Context, person *entity.Person) error {
+	person.Email = normalizeEmail(person.Email)
+
+	existing, err := s.personRepository.FindByEmail(ctx, person.Email)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		*person = existing[0]
+		return ErrDuplicateEmail
+	}
+
+	created, err := s.personRepository.Create(ctx, *person)
+	if err != nil {
+		return err
+	}
+	person.ID = created.ID
+
+	logging.FromContext(ctx).Info("person created successfully", slog.Uint64("person_id", created.ID))
+	return nil
+}
+
+func (s *personService) FindByID(ctx context.Context, id uint64) (*entity.Person, error) {
+	return s.personRepository.GetByID(ctx, id)
+}
+
+func (s *personService) FindAll(ctx context.Context) ([]entity.Person, error) {
+	return s.personRepository.List(ctx)
+}
+
+func (s *personService) Update(ctx context.Context, person *entity.Person) error {
+	if _, err := s.personRepository.GetByID(ctx, person.ID); err != nil {
+		return err
+	}
+
+	person.Email = normalizeEmail(person.Email)
+	existing, err := s.personRepository.FindByEmail(ctx, person.Email)
+	if err != nil {
+		return err
+	}
+	for _, p := range existing {
+		if p.ID != person.ID {
+			return ErrDuplicateEmail
+		}
+	}
+
+	if err := s.personRepository.Update(ctx, *person); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("person updated successfully", slog.Uint64("person_id", person.ID))
+	return nil
+}
+
+func (s *personService) Delete(ctx context.Context, id uint64) error {
+	if _, err := s.personRepository.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	count, err := s.personRepository.CountVideos(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPersonHasVideos
+	}
+
+	if err := s.personRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("person deleted successfully", slog.Uint64("person_id", id))
+	return nil
+}
+
+func (s *personService) Videos(ctx context.Context, id uint64) ([]entity.Video, error) {
+	if _, err := s.personRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.videoRepository.FindByAuthor(ctx, id)
+}
+
+// Duplicates groups persons that share the same normalized email.
+func (s *personService) Duplicates(ctx context.Context) (map[string][]entity.Person, error) {
+	emails, err := s.personRepository.DuplicateEmails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]entity.Person, len(emails))
+	for _, email := range emails {
+		persons, err := s.personRepository.FindByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		groups[email] = persons
+	}
+	return groups, nil
+}
+
+// Merge moves all videos of sourceID onto targetID and deletes sourceID.
+func (s *personService) Merge(ctx context.Context, targetID, sourceID uint64) (*entity.Person, error) {
+	if targetID == sourceID {
+		return nil, ErrSelfMerge
+	}
+
+	target, err := s.personRepository.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.personRepository.GetByID(ctx, sourceID); err != nil {
+		return nil, err
+	}
+
+	if err := s.personRepository.Merge(ctx, targetID, sourceID); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("persons merged successfully",
+		slog.Uint64("target_id", targetID),
+		slog.Uint64("source_id", sourceID),
+	)
+	return target, nil
+}
+
+func (s *personService) Resolve(ctx context.Context, personID uint64, author *entity.Person) (*entity.Person, error) {
+	if personID != 0 {
+		return s.personRepository.GetByID(ctx, personID)
+	}
+	if author == nil {
+		return nil, errors.New("video author is required")
+	}
+	if author.ID != 0 {
+		return s.personRepository.GetByID(ctx, author.ID)
+	}
+
+	err := s.Create(ctx, author)
+	if err != nil && !errors.Is(err, ErrDuplicateEmail) {
+		return nil, err
+	}
+	return author, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoStudentRecord  = errors.New("no student record is linked to this account")
+	ErrStudentLinked    = errors.New("student record is already linked to another account")
+	ErrStaffNotLinkable = errors.New("staff accounts cannot be linked to a student or guardian record")
+)
+
+// Profile is the caller's own account, with their student record when
+// they are a student, or their guardian record and students when they are
+// a guardian.
+type Profile struct {
+	Username string           `json:"username"`
+	Role     string           `json:"role"`
+	Student  *entity.Student  `json:"student,omitempty"`
+	Guardian *entity.Guardian `json:"guardian,omitempty"`
+	Students []entity.Student `json:"students,omitempty"`
+}
+
+// ProfileUpdate holds the fields students may change on their own record,
+// their addresses and phone numbers. Unset fields are left alone. The
+// email is not among them: a verified student email links accounts, so
+// only staff change it.
+type ProfileUpdate struct {
+	Addresses *[]entity.Address `json:"addresses" binding:"omitempty,dive"`
+	Phones    *[]entity.Phone   `json:"phones" binding:"omitempty,dive"`
+}
+
+// ProfileService gives student accounts access to their own record and
+// guardian accounts to the records of their students. Accounts are linked
+// to a record by staff, or when a registered account verifies the email
+// address of a record of its tenant.
+type ProfileService interface {
+	Me(ctx context.Context, username, role string) (Profile, error)
+	// CanView reports whether the account may see the student record:
+	// staff see every record, students their own and guardians those they
+	// are linked to.
+	CanView(ctx context.Context, username, role string, studentID int) (bool, error)
+	UpdateMe(ctx context.Context, username string, update ProfileUpdate) (*entity.Student, error)
+	// StudentID returns the ID of the record the account is linked to, or
+	// ErrNoStudentRecord.
+	StudentID(ctx context.Context, username string) (int, error)
+	Link(ctx context.Context, studentID int, username string) error
+	Unlink(ctx context.Context, studentID int) error
+}
+
+type profileService struct {
+	users          repository.UserRepository
+	guardians      repository.GuardianRepository
+	studentService StudentService
+}
+
+func NewProfileService(users repository.UserRepository, guardians repository.GuardianRepository, studentService StudentService) ProfileService {
+	return &profileService{
+		users:          users,
+		guardians:      guardians,
+		studentService: studentService,
+	}
+}
+
+func (s *profileService) StudentID(ctx context.Context, username string) (int, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrNoStudentRecord
+	}
+	if err != nil {
+		return 0, err
+	}
+	if user.Role != RoleStudent || user.StudentID == nil {
+		return 0, ErrNoStudentRecord
+	}
+	return *user.StudentID, nil
+}
+
+// guardianID returns the ID of the guardian record the account is linked
+// to, or false when there is none.
+func (s *profileService) guardianID(ctx context.Context, username string) (uint64, bool, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if user.Role != RoleGuardian || user.GuardianID == nil {
+		return 0, false, nil
+	}
+	return *user.GuardianID, true, nil
+}
+
+func (s *profileService) CanView(ctx context.Context, username, role string, studentID int) (bool, error) {
+	switch role {
+	case RoleStudent:
+		own, err := s.StudentID(ctx, username)
+		if errors.Is(err, ErrNoStudentRecord) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return own == studentID, nil
+	case RoleGuardian:
+		id, ok, err := s.guardianID(ctx, username)
+		if err != nil || !ok {
+			return false, err
+		}
+		return s.guardians.IsLinked(ctx, studentID, id)
+	default:
+		return IsStaff(role), nil
+	}
+}
+
+func (s *profileService) Me(ctx context.Context, username, role string) (Profile, error) {
+	profile := Profile{Username: username, Role: role}
+	if role == RoleGuardian {
+		return s.guardianProfile(ctx, profile)
+	}
+	if role != RoleStudent {
+		return profile, nil
+	}
+
+	id, err := s.StudentID(ctx, username)
+	if errors.Is(err, ErrNoStudentRecord) {
+		return profile, nil
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	student, err := s.studentService.FindByID(ctx, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return profile, nil
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	profile.Student = student
+	return profile, nil
+}
+
+func (s *profileService) guardianProfile(ctx context.Context, profile Profile) (Profile, error) {
+	id, ok, err := s.guardianID(ctx, profile.Username)
+	if err != nil {
+		return Profile{}, err
+	}
+	if !ok {
+		return profile, nil
+	}
+	guardian, err := s.guardians.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return profile, nil
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	students, err := s.guardians.Students(ctx, id)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile.Guardian = guardian
+	profile.Students = students
+	return profile, nil
+}
+
+func (s *profileService) UpdateMe(ctx context.Context, username string, update ProfileUpdate) (*entity.Student, error) {
+	id, err := s.StudentID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	student, err := s.studentService.FindByID(ctx, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoStudentRecord
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Addresses != nil {
+		student.Addresses = *update.Addresses
+	}
+	if update.Phones != nil {
+		student.Phones = *update.Phones
+	}
+
+	if err := s.studentService.Update(ctx, student); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("student updated own record", slog.Int("student_id", student.ID))
+	return student, nil
+}
+
+func (s *profileService) Link(ctx context.Context, studentID int, username string) error {
+	if _, err := s.studentService.FindByID(ctx, uint(studentID)); err != nil {
+		return err
+	}
+	user, err := s.users.FindByUsername(ctx, normalizeUsername(username))
+	if err != nil {
+		return err
+	}
+	// A linked staff account would keep passing staff checks with the
+	// tokens it already holds.
+	if IsStaff(user.Role) {
+		return ErrStaffNotLinkable
+	}
+	if user.GuardianID != nil {
+		return ErrAccountLinked
+	}
+	if linked, err := s.users.FindByStudent(ctx, studentID); err == nil {
+		if linked.ID == user.ID {
+			return nil
+		}
+		return ErrStudentLinked
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := s.users.SetStudent(ctx, user.ID, &studentID, RoleStudent); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("student account linked", slog.Uint64("user_id", user.ID), slog.Int("student_id", studentID))
+	return nil
+}
+
+// Unlink detaches the account linked to the record. The account keeps the
+// student role, so it does not gain staff access.
+func (s *profileService) Unlink(ctx context.Context, studentID int) error {
+	user, err := s.users.FindByStudent(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetStudent(ctx, user.ID, nil, RoleStudent); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("student account unlinked", slog.Uint64("user_id", user.ID), slog.Int("student_id", studentID))
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/db"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns a tenant scoped SQLite database with the Postgres
+// models, and a context of tenant 1.
+func newTestDB(t *testing.T) (*gorm.DB, context.Context) {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open(t.TempDir()+"/t.db"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tenant.ScopeGorm(gdb); err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.AutoMigrate(db.PostgresModels()...); err != nil {
+		t.Fatal(err)
+	}
+	return gdb, WithTenant(context.Background(), &entity.Tenant{ID: 1, Slug: "main"})
+}
+
+// create inserts records, failing the test on error.
+func create(t *testing.T, gdb *gorm.DB, ctx context.Context, records ...any) {
+	t.Helper()
+	for _, record := range records {
+		if err := gdb.WithContext(ctx).Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestProfileService(t *testing.T, gdb *gorm.DB) ProfileService {
+	t.Helper()
+	lifecycle, err := NewLifecycle(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	students := NewStudentService(repository.New(gdb), cache.NewLRU(100), NewCacheStats(), "{seq}", lifecycle)
+	return NewProfileService(repository.NewUserRepository(gdb), repository.NewGuardianRepository(gdb), students)
+}
+
+func TestCanViewStudent(t *testing.T) {
+	gdb, ctx := newTestDB(t)
+	own, other := 1, 2
+	create(t, gdb, ctx,
+		&entity.Student{ID: own, Name: "Ada Lovelace", Email: "ada@example.com"},
+		&entity.Student{ID: other, Name: "Grace Hopper", Email: "grace@example.com"},
+		&entity.User{Username: "ada", Role: RoleStudent, StudentID: &own},
+		&entity.User{Username: "new", Role: RoleStudent},
+	)
+	profiles := newTestProfileService(t, gdb)
+
+	tests := []struct {
+		name     string
+		username string
+		role     string
+		student  int
+		want     bool
+	}{
+		{"student sees own record", "ada", RoleStudent, own, true},
+		{"student cannot see another record", "ada", RoleStudent, other, false},
+		{"unlinked student sees nothing", "new", RoleStudent, own, false},
+		{"unknown student account sees nothing", "ghost", RoleStudent, own, false},
+		{"admin sees every record", "admin", RoleAdmin, other, true},
+		{"staff user sees every record", "clerk", RoleUser, other, true},
+		{"no role sees nothing", "ada", "", own, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := profiles.CanView(ctx, tt.username, tt.role, tt.student)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("CanView(%q, %q, %d) = %v, want %v", tt.username, tt.role, tt.student, got, tt.want)
+			}
+		})
+	}
+
+	// Another tenant's account with the same name is not the owner.
+	otherTenant := WithTenant(context.Background(), &entity.Tenant{ID: 2, Slug: "north"})
+	if got, err := profiles.CanView(otherTenant, "ada", RoleStudent, own); err != nil || got {
+		t.Errorf("CanView from another tenant = %v, %v, want false", got, err)
+	}
+}
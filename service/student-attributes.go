@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+)
+
+// maxAttributeText caps the length of a string attribute value.
+const maxAttributeText = 1000
+
+var ErrInvalidStudent = errors.New("invalid student")
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// validateAttributeDefinitions checks a tenant's custom attribute schema.
+func validateAttributeDefinitions(defs []entity.StudentAttribute) error {
+	seen := map[string]bool{}
+	for _, def := range defs {
+		if !attributeKeyPattern.MatchString(def.Key) {
+			return fmt.Errorf("%w: attribute key %q must be lower case letters, digits and underscores", ErrTenantSettings, def.Key)
+		}
+		if seen[def.Key] {
+			return fmt.Errorf("%w: attribute %q is defined twice", ErrTenantSettings, def.Key)
+		}
+		seen[def.Key] = true
+
+		switch def.Type {
+		case entity.AttributeString, entity.AttributeNumber, entity.AttributeInteger, entity.AttributeBoolean, entity.AttributeDate:
+			if len(def.Options) > 0 {
+				return fmt.Errorf("%w: only enum attributes have options, not %q", ErrTenantSettings, def.Key)
+			}
+		case entity.AttributeEnum:
+			if len(def.Options) == 0 {
+				return fmt.Errorf("%w: enum attribute %q needs options", ErrTenantSettings, def.Key)
+			}
+		default:
+			return fmt.Errorf("%w: attribute %q has unknown type %q", ErrTenantSettings, def.Key, def.Type)
+		}
+	}
+	return nil
+}
+
+// validateAttributes checks a student's custom attributes against the
+// tenant's schema. Attributes set to null are removed.
+func validateAttributes(defs []entity.StudentAttribute, values map[string]any) error {
+	for key, value := range values {
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+		i := slices.IndexFunc(defs, func(def entity.StudentAttribute) bool { return def.Key == key })
+		if i < 0 {
+			return fmt.Errorf("%w: unknown custom attribute %q", ErrInvalidStudent, key)
+		}
+		if err := checkAttribute(defs[i], value); err != nil {
+			return fmt.Errorf("%w: custom attribute %q %s", ErrInvalidStudent, key, err.Error())
+		}
+	}
+	for _, def := range defs {
+		if _, ok := values[def.Key]; def.Required && !ok {
+			return fmt.Errorf("%w: custom attribute %q is required", ErrInvalidStudent, def.Key)
+		}
+	}
+	return nil
+}
+
+// checkAttribute checks one value as decoded from JSON.
+func checkAttribute(def entity.StudentAttribute, value any) error {
+	switch def.Type {
+	case entity.AttributeString:
+		if s, ok := value.(string); !ok || len(s) > maxAttributeText {
+			return fmt.Errorf("must be a string of at most %d bytes", maxAttributeText)
+		}
+	case entity.AttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return errors.New("must be a number")
+		}
+	case entity.AttributeInteger:
+		if f, ok := value.(float64); !ok || f != math.Trunc(f) {
+			return errors.New("must be a whole number")
+		}
+	case entity.AttributeBoolean:
+		if _, ok := value.(bool); !ok {
+			return errors.New("must be true or false")
+		}
+	case entity.AttributeDate:
+		s, ok := value.(string)
+		if !ok {
+			return errors.New("must be a date in YYYY-MM-DD format")
+		}
+		if _, err := entity.ParseDate(s); err != nil {
+			return errors.New("must be a date in YYYY-MM-DD format")
+		}
+	case entity.AttributeEnum:
+		if s, ok := value.(string); !ok || !slices.Contains(def.Options, s) {
+			return fmt.Errorf("must be one of %v", def.Options)
+		}
+	}
+	return nil
+}
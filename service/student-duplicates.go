@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Reasons a pair of students is reported as a duplicate candidate.
+const (
+	DuplicateSameEmail    = "same_email"
+	DuplicateSimilarEmail = "similar_email"
+	DuplicateSameName     = "same_name"
+	DuplicateSimilarName  = "similar_name"
+	DuplicateSameBirth    = "same_date_of_birth"
+)
+
+const (
+	// similarName is the name similarity, from 0 to 1, above which two
+	// names are taken for the same name with typos.
+	similarName = 0.8
+	// maxEmailTypos is how many edits apart two emails may be to count as
+	// the same email with typos.
+	maxEmailTypos = 2
+)
+
+var ErrMergeAccounts = errors.New("both students have a portal account, unlink one of them first")
+
+// DuplicateCandidate is a pair of students that are probably the same
+// person. Score runs from 0 to 1; Reasons says what matched.
+type DuplicateCandidate struct {
+	Students [2]entity.Student `json:"students"`
+	Score    float64           `json:"score"`
+	Reasons  []string          `json:"reasons"`
+}
+
+// MergeRequest merges the student DuplicateID into another. Take lists the
+// fields whose value comes from the duplicate; every other field keeps the
+// survivor's value, or the duplicate's when the survivor has none. Custom
+// attributes are combined.
+type MergeRequest struct {
+	DuplicateID int      `json:"duplicate_id" binding:"required"`
+	Take        []string `json:"take" binding:"dive,oneof=student_number name email date_of_birth enrollment_status addresses phones custom_attributes"`
+}
+
+// MergeHook moves what belongs to a merged student, and is kept outside
+// the student database, to the student it is merged into.
+type MergeHook func(ctx context.Context, from, into int) error
+
+// duplicateEmail normalizes an email for comparison: case and a "+tag"
+// after the local part are ignored.
+func duplicateEmail(email string) string {
+	email = normalizeEmail(email)
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	local, _, _ = strings.Cut(local, "+")
+	return local + "@" + domain
+}
+
+// duplicateName normalizes a name for comparison: case, punctuation and
+// the order of the words are ignored, so "Lovelace, Ada" is "ada lovelace".
+func duplicateName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// similarity is 1 for equal strings and falls towards 0 with every edit.
+func similarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+// duplicateKey holds the normalized values of one student.
+type duplicateKey struct {
+	email string
+	name  string
+	birth string
+}
+
+// compareStudents scores a pair. A pair is a candidate when the emails
+// match, when the names are similar and the dates of birth the same, or
+// when both the emails and the names are similar.
+func compareStudents(a, b duplicateKey) (float64, []string, bool) {
+	var reasons []string
+	score := 0.0
+
+	similarEmail := false
+	switch {
+	case a.email == b.email:
+		reasons = append(reasons, DuplicateSameEmail)
+		score += 0.5
+	case min(len(a.email), len(b.email)) > 2*maxEmailTypos && editDistance(a.email, b.email) <= maxEmailTypos:
+		similarEmail = true
+		reasons = append(reasons, DuplicateSimilarEmail)
+		score += 0.3
+	}
+
+	nameScore := similarity(a.name, b.name)
+	switch {
+	case a.name == b.name:
+		reasons = append(reasons, DuplicateSameName)
+	case nameScore >= similarName:
+		reasons = append(reasons, DuplicateSimilarName)
+	}
+	score += 0.3 * nameScore
+
+	sameBirth := a.birth != "" && a.birth == b.birth
+	if sameBirth {
+		reasons = append(reasons, DuplicateSameBirth)
+		score += 0.2
+	}
+
+	candidate := a.email == b.email ||
+		(nameScore >= similarName && sameBirth) ||
+		(similarEmail && nameScore >= similarName)
+	return math.Round(min(score, 1)*100) / 100, reasons, candidate
+}
+
+// findDuplicates reports the candidate pairs among students, best first.
+// Only students that share an email, a date of birth or a word of their
+// name are compared, which keeps large tenants from comparing every pair.
+func findDuplicates(students []entity.Student) []DuplicateCandidate {
+	keys := make([]duplicateKey, len(students))
+	blocks := map[string][]int{}
+	for i, student := range students {
+		key := duplicateKey{email: duplicateEmail(student.Email), name: duplicateName(student.Name)}
+		if student.DateOfBirth != nil {
+			key.birth = student.DateOfBirth.String()
+			blocks["birth:"+key.birth] = append(blocks["birth:"+key.birth], i)
+		}
+		keys[i] = key
+		blocks["email:"+key.email] = append(blocks["email:"+key.email], i)
+		for _, word := range slices.Compact(strings.Fields(key.name)) {
+			blocks["name:"+word] = append(blocks["name:"+word], i)
+		}
+	}
+
+	seen := map[[2]int]bool{}
+	candidates := []DuplicateCandidate{}
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				pair := [2]int{block[x], block[y]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				score, reasons, ok := compareStudents(keys[pair[0]], keys[pair[1]])
+				if !ok {
+					continue
+				}
+				candidate := DuplicateCandidate{
+					Students: [2]entity.Student{students[pair[0]], students[pair[1]]},
+					Score:    score,
+					Reasons:  reasons,
+				}
+				if candidate.Students[0].ID > candidate.Students[1].ID {
+					candidate.Students[0], candidate.Students[1] = candidate.Students[1], candidate.Students[0]
+				}
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Students[0].ID != b.Students[0].ID {
+			return a.Students[0].ID < b.Students[0].ID
+		}
+		return a.Students[1].ID < b.Students[1].ID
+	})
+	return candidates
+}
+
+// mergeStudents returns the survivor with the fields in take, and those
+// it lacks, filled in from the duplicate.
+func mergeStudents(survivor, duplicate entity.Student, take []string) entity.Student {
+	merged := survivor
+	from := func(field string, empty bool) bool {
+		return slices.Contains(take, field) || empty
+	}
+	if from("student_number", merged.StudentNumber == "") {
+		merged.StudentNumber = duplicate.StudentNumber
+	}
+	if from("name", merged.Name == "") {
+		merged.Name = duplicate.Name
+	}
+	if from("email", merged.Email == "") {
+		merged.Email = duplicate.Email
+	}
+	if from("date_of_birth", merged.DateOfBirth == nil) {
+		merged.DateOfBirth = duplicate.DateOfBirth
+		merged.BirthDateEstimated = duplicate.BirthDateEstimated
+	}
+	if from("enrollment_status", merged.EnrollmentStatus == "") {
+		merged.EnrollmentStatus = duplicate.EnrollmentStatus
+	}
+	if from("addresses", len(merged.Addresses) == 0) {
+		merged.Addresses = duplicate.Addresses
+	}
+	if from("phones", len(merged.Phones) == 0) {
+		merged.Phones = duplicate.Phones
+	}
+
+	attributes := map[string]any{}
+	if slices.Contains(take, "custom_attributes") {
+		maps.Copy(attributes, survivor.CustomAttributes)
+		maps.Copy(attributes, duplicate.CustomAttributes)
+	} else {
+		maps.Copy(attributes, duplicate.CustomAttributes)
+		maps.Copy(attributes, survivor.CustomAttributes)
+	}
+	if len(attributes) > 0 {
+		merged.CustomAttributes = attributes
+	}
+	return merged
+}
+
+func (s *studentService) Duplicates(ctx context.Context) ([]DuplicateCandidate, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.Duplicates")
+	defer span.End()
+
+	students, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := findDuplicates(students)
+
+	logging.FromContext(ctx).Info("duplicate students found", slog.Int("students", len(students)), slog.Int("candidates", len(candidates)))
+	return candidates, nil
+}
+
+func (s *studentService) OnMerge(hook MergeHook) {
+	s.mergeHooks = append(s.mergeHooks, hook)
+}
+
+func (s *studentService) Merge(ctx context.Context, id int, req MergeRequest, actor string) (*entity.Student, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.Merge",
+		trace.WithAttributes(attribute.Int("student.id", id), attribute.Int("student.duplicate_id", req.DuplicateID)))
+	defer span.End()
+
+	if req.DuplicateID == id {
+		return nil, fmt.Errorf("%w: a student cannot be merged into itself", ErrInvalidStudent)
+	}
+	survivor, err := s.repo.GetByID(ctx, int64(id))
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := s.repo.GetByID(ctx, int64(req.DuplicateID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Repeating a merge that was saved moves the records in other
+		// databases again, in case that failed the first time.
+		if into, mergeErr := s.repo.MergedInto(ctx, req.DuplicateID); mergeErr == nil && into == survivor.ID {
+			if err := s.runMergeHooks(ctx, req.DuplicateID, survivor.ID); err != nil {
+				return nil, err
+			}
+			return survivor, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	survivorAccount, err := s.repo.HasAccount(ctx, survivor.ID)
+	if err != nil {
+		return nil, err
+	}
+	duplicateAccount, err := s.repo.HasAccount(ctx, duplicate.ID)
+	if err != nil {
+		return nil, err
+	}
+	if survivorAccount && duplicateAccount {
+		return nil, ErrMergeAccounts
+	}
+
+	merged := mergeStudents(*survivor, *duplicate, req.Take)
+	var transition *entity.StudentTransition
+	if merged.EnrollmentStatus != survivor.EnrollmentStatus {
+		// Taking the duplicate's status is a transition like any other.
+		if err := s.lifecycle.check(ctx, survivor, merged.EnrollmentStatus); err != nil {
+			return nil, err
+		}
+		transition = &entity.StudentTransition{
+			StudentID:  survivor.ID,
+			FromStatus: survivor.EnrollmentStatus,
+			ToStatus:   merged.EnrollmentStatus,
+			Reason:     fmt.Sprintf("Merged with student %d", duplicate.ID),
+			Actor:      actor,
+		}
+	}
+
+	if err := s.repo.Merge(ctx, merged, duplicate.ID, transition); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, survivor.ID)
+	s.invalidate(ctx, duplicate.ID)
+	if transition != nil {
+		s.lifecycle.entered(ctx, &merged, transition)
+	}
+
+	// Records in other databases move once the merge is saved, so a failed
+	// merge leaves them where they were.
+	if err := s.runMergeHooks(ctx, duplicate.ID, survivor.ID); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("students merged",
+		slog.Int("student_id", survivor.ID),
+		slog.Int("duplicate_id", duplicate.ID),
+		slog.Any("take", req.Take),
+		slog.String("actor", actor),
+	)
+	return &merged, nil
+}
+
+// runMergeHooks moves what the merged student from keeps in other
+// databases to into. The hooks move nothing the second time, so a failure
+// is retried by repeating the merge.
+func (s *studentService) runMergeHooks(ctx context.Context, from, into int) error {
+	for _, hook := range s.mergeHooks {
+		if err := hook(ctx, from, into); err != nil {
+			return fmt.Errorf("student %d was merged into %d, but moving its records failed, repeat the merge to retry: %w", from, into, err)
+		}
+	}
+	return nil
+}
+
+func (s *studentService) MergedInto(ctx context.Context, id int) (int, error) {
+	return s.repo.MergedInto(ctx, id)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+)
+
+func TestCompareStudents(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      duplicateKey
+		score     float64
+		reasons   []string
+		candidate bool
+	}{
+		{
+			name:      "same person",
+			a:         duplicateKey{email: "ada@example.com", name: "ada lovelace", birth: "1815-12-10"},
+			b:         duplicateKey{email: "ada@example.com", name: "ada lovelace", birth: "1815-12-10"},
+			score:     1,
+			reasons:   []string{DuplicateSameEmail, DuplicateSameName, DuplicateSameBirth},
+			candidate: true,
+		},
+		{
+			name:      "same email only",
+			a:         duplicateKey{email: "ada@example.com", name: "ada lovelace"},
+			b:         duplicateKey{email: "ada@example.com", name: "grace hopper"},
+			score:     0.53,
+			reasons:   []string{DuplicateSameEmail},
+			candidate: true,
+		},
+		{
+			name:      "similar name and same birth",
+			a:         duplicateKey{email: "ada@example.com", name: "ada lovelace", birth: "1815-12-10"},
+			b:         duplicateKey{email: "countess@example.org", name: "ada lovelase", birth: "1815-12-10"},
+			score:     0.48,
+			reasons:   []string{DuplicateSimilarName, DuplicateSameBirth},
+			candidate: true,
+		},
+		{
+			name:      "similar email and same name",
+			a:         duplicateKey{email: "ada@example.com", name: "ada lovelace"},
+			b:         duplicateKey{email: "ada1@example.com", name: "ada lovelace"},
+			score:     0.6,
+			reasons:   []string{DuplicateSimilarEmail, DuplicateSameName},
+			candidate: true,
+		},
+		{
+			name:    "same name only",
+			a:       duplicateKey{email: "ada@example.com", name: "ada lovelace", birth: "1815-12-10"},
+			b:       duplicateKey{email: "lovelace@example.org", name: "ada lovelace", birth: "1990-01-01"},
+			score:   0.3,
+			reasons: []string{DuplicateSameName},
+		},
+		{
+			name:    "short emails are not similar",
+			a:       duplicateKey{email: "a@b", name: "ada lovelace"},
+			b:       duplicateKey{email: "c@b", name: "ada lovelace"},
+			score:   0.3,
+			reasons: []string{DuplicateSameName},
+		},
+		{
+			name:  "unknown births do not match",
+			a:     duplicateKey{email: "ada@example.com", name: "ada lovelace"},
+			b:     duplicateKey{email: "grace@example.org", name: "grace hopper"},
+			score: 0.03,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reasons, candidate := compareStudents(tt.a, tt.b)
+			if score != tt.score || !slices.Equal(reasons, tt.reasons) || candidate != tt.candidate {
+				t.Errorf("compareStudents = (%v, %v, %v), want (%v, %v, %v)",
+					score, reasons, candidate, tt.score, tt.reasons, tt.candidate)
+			}
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	date := func(s string) *entity.Date {
+		d, err := entity.ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	students := []entity.Student{
+		{ID: 5, Name: "Alan Turing", Email: "alan@example.com"},
+		{ID: 4, Name: "Grace Hoper", Email: "ghopper@navy.mil", DateOfBirth: date("1906-12-09")},
+		{ID: 2, Name: "Lovelace, Ada", Email: "ADA+school@Example.com", DateOfBirth: date("1815-12-10")},
+		{ID: 3, Name: "Grace Hopper", Email: "grace@navy.mil", DateOfBirth: date("1906-12-09")},
+		{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", DateOfBirth: date("1815-12-10")},
+		{ID: 6, Name: "Alan Kay", Email: "kay@example.org", DateOfBirth: date("1940-05-17")},
+	}
+
+	tests := []struct {
+		pair    [2]int
+		score   float64
+		reasons []string
+	}{
+		{[2]int{1, 2}, 1, []string{DuplicateSameEmail, DuplicateSameName, DuplicateSameBirth}},
+		{[2]int{3, 4}, 0.48, []string{DuplicateSimilarName, DuplicateSameBirth}},
+	}
+
+	candidates := findDuplicates(students)
+	if len(candidates) != len(tests) {
+		t.Fatalf("findDuplicates returned %d candidates, want %d: %+v", len(candidates), len(tests), candidates)
+	}
+	for i, tt := range tests {
+		c := candidates[i]
+		pair := [2]int{c.Students[0].ID, c.Students[1].ID}
+		if pair != tt.pair || c.Score != tt.score || !slices.Equal(c.Reasons, tt.reasons) {
+			t.Errorf("candidate %d = (%v, %v, %v), want (%v, %v, %v)", i, pair, c.Score, c.Reasons, tt.pair, tt.score, tt.reasons)
+		}
+	}
+
+	if got := findDuplicates(nil); got == nil || len(got) != 0 {
+		t.Errorf("findDuplicates(nil) = %#v, want an empty slice", got)
+	}
+}
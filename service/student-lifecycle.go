@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownStatus        = errors.New("unknown enrollment status")
+	ErrTransitionNotAllowed = errors.New("transition not allowed")
+	ErrStatusChanged        = errors.New("the student's enrollment status has changed, reload and try again")
+	ErrStatusNotEditable    = errors.New("enrollment_status can only be changed through POST /api/students/{id}/transitions")
+)
+
+// EnrollmentStatuses lists every enrollment status a student can have.
+var EnrollmentStatuses = []string{
+	entity.EnrollmentApplicant,
+	entity.EnrollmentEnrolled,
+	entity.EnrollmentSuspended,
+	entity.EnrollmentGraduated,
+	entity.EnrollmentWithdrawn,
+}
+
+// DefaultTransitions is the lifecycle used when the configuration does not
+// define one: applicants enroll, enrolled students are suspended,
+// graduate or withdraw, suspended students come back or withdraw and
+// withdrawn students may apply again.
+var DefaultTransitions = map[string][]string{
+	entity.EnrollmentApplicant: {entity.EnrollmentEnrolled, entity.EnrollmentWithdrawn},
+	entity.EnrollmentEnrolled:  {entity.EnrollmentSuspended, entity.EnrollmentGraduated, entity.EnrollmentWithdrawn},
+	entity.EnrollmentSuspended: {entity.EnrollmentEnrolled, entity.EnrollmentWithdrawn},
+	entity.EnrollmentGraduated: {},
+	entity.EnrollmentWithdrawn: {entity.EnrollmentApplicant},
+}
+
+// TransitionGuard can refuse to move the student to the status to; its
+// error is returned to the client.
+type TransitionGuard func(ctx context.Context, student *entity.Student, to string) error
+
+// TransitionHook runs after a student moved into a status, e.g. to detach
+// what a withdrawn student no longer needs. The transition is already
+// saved, so a failing hook is only logged.
+type TransitionHook func(ctx context.Context, student *entity.Student, transition *entity.StudentTransition) error
+
+// Lifecycle is the state machine of the enrollment status: the allowed
+// transitions plus the guards and hooks registered per target status.
+// Guards and hooks are registered at startup, before requests are served.
+type Lifecycle struct {
+	transitions map[string][]string
+	guards      map[string][]TransitionGuard
+	hooks       map[string][]TransitionHook
+}
+
+// NewLifecycle checks the transitions and builds the state machine. An
+// empty map selects DefaultTransitions.
+func NewLifecycle(transitions map[string][]string) (*Lifecycle, error) {
+	if len(transitions) == 0 {
+		transitions = DefaultTransitions
+	}
+	for from, targets := range transitions {
+		if !slices.Contains(EnrollmentStatuses, from) {
+			return nil, fmt.Errorf("%w %q in transitions", ErrUnknownStatus, from)
+		}
+		for _, to := range targets {
+			if !slices.Contains(EnrollmentStatuses, to) {
+				return nil, fmt.Errorf("%w %q in transitions from %q", ErrUnknownStatus, to, from)
+			}
+			if to == from {
+				return nil, fmt.Errorf("transition from %q to itself", from)
+			}
+		}
+	}
+	return &Lifecycle{
+		transitions: transitions,
+		guards:      map[string][]TransitionGuard{},
+		hooks:       map[string][]TransitionHook{},
+	}, nil
+}
+
+// Guard registers a guard for transitions into the status to.
+func (l *Lifecycle) Guard(to string, guard TransitionGuard) {
+	l.guards[to] = append(l.guards[to], guard)
+}
+
+// OnEnter registers a hook for transitions into the status to.
+func (l *Lifecycle) OnEnter(to string, hook TransitionHook) {
+	l.hooks[to] = append(l.hooks[to], hook)
+}
+
+// Allowed reports whether a student may move from one status to another.
+func (l *Lifecycle) Allowed(from, to string) bool {
+	return slices.Contains(l.transitions[from], to)
+}
+
+// Next returns the statuses a student in status from may move to.
+func (l *Lifecycle) Next(from string) []string {
+	next := slices.Clone(l.transitions[from])
+	sort.Strings(next)
+	return next
+}
+
+// check runs the guards of the transition.
+func (l *Lifecycle) check(ctx context.Context, student *entity.Student, to string) error {
+	if !slices.Contains(EnrollmentStatuses, to) {
+		return fmt.Errorf("%w %q", ErrUnknownStatus, to)
+	}
+	if !l.Allowed(student.EnrollmentStatus, to) {
+		return fmt.Errorf("%w from %q to %q, allowed: %v", ErrTransitionNotAllowed, student.EnrollmentStatus, to, l.Next(student.EnrollmentStatus))
+	}
+	for _, guard := range l.guards[to] {
+		if err := guard(ctx, student, to); err != nil {
+			return fmt.Errorf("%w: %s", ErrTransitionNotAllowed, err.Error())
+		}
+	}
+	return nil
+}
+
+// entered runs the hooks of the status the student moved into.
+func (l *Lifecycle) entered(ctx context.Context, student *entity.Student, transition *entity.StudentTransition) {
+	for _, hook := range l.hooks[transition.ToStatus] {
+		if err := hook(ctx, student, transition); err != nil {
+			logging.FromContext(ctx).Error("student transition hook failed",
+				slog.Int("student_id", student.ID),
+				slog.String("to", transition.ToStatus),
+				"error", err,
+			)
+		}
+	}
+}
+
+// DetachAccount is a hook that unlinks the student's portal account, so a
+// withdrawn student can no longer see the record.
+func DetachAccount(profiles ProfileService) TransitionHook {
+	return func(ctx context.Context, student *entity.Student, _ *entity.StudentTransition) error {
+		err := profiles.Unlink(ctx, student.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+)
+
+func TestNewLifecycle(t *testing.T) {
+	tests := []struct {
+		name        string
+		transitions map[string][]string
+		wantErr     bool
+	}{
+		{"default", nil, false},
+		{"custom", map[string][]string{entity.EnrollmentApplicant: {entity.EnrollmentEnrolled}}, false},
+		{"unknown source", map[string][]string{"on_leave": {entity.EnrollmentEnrolled}}, true},
+		{"unknown target", map[string][]string{entity.EnrollmentEnrolled: {"expelled"}}, true},
+		{"to itself", map[string][]string{entity.EnrollmentEnrolled: {entity.EnrollmentEnrolled}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLifecycle(tt.transitions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewLifecycle error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultTransitions(t *testing.T) {
+	lifecycle, err := NewLifecycle(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		from string
+		next []string
+	}{
+		{entity.EnrollmentApplicant, []string{entity.EnrollmentEnrolled, entity.EnrollmentWithdrawn}},
+		{entity.EnrollmentEnrolled, []string{entity.EnrollmentGraduated, entity.EnrollmentSuspended, entity.EnrollmentWithdrawn}},
+		{entity.EnrollmentSuspended, []string{entity.EnrollmentEnrolled, entity.EnrollmentWithdrawn}},
+		{entity.EnrollmentGraduated, []string{}},
+		{entity.EnrollmentWithdrawn, []string{entity.EnrollmentApplicant}},
+	}
+	for _, tt := range tests {
+		if got := lifecycle.Next(tt.from); !slices.Equal(got, tt.next) {
+			t.Errorf("Next(%q) = %v, want %v", tt.from, got, tt.next)
+		}
+		for _, to := range EnrollmentStatuses {
+			if got, want := lifecycle.Allowed(tt.from, to), slices.Contains(tt.next, to); got != want {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", tt.from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	errNoDiploma := errors.New("no diploma on file")
+
+	tests := []struct {
+		name       string
+		from       string
+		req        TransitionRequest
+		wantErr    error
+		wantStatus string
+		wantHook   bool
+	}{
+		{"allowed", entity.EnrollmentEnrolled, TransitionRequest{To: entity.EnrollmentSuspended, Reason: "unpaid fees"}, nil, entity.EnrollmentSuspended, false},
+		{"hook runs on entry", entity.EnrollmentEnrolled, TransitionRequest{To: entity.EnrollmentWithdrawn, Reason: "moved away"}, nil, entity.EnrollmentWithdrawn, true},
+		{"expected status matches", entity.EnrollmentApplicant, TransitionRequest{To: entity.EnrollmentEnrolled, From: entity.EnrollmentApplicant, Reason: "accepted"}, nil, entity.EnrollmentEnrolled, false},
+		{"expected status changed", entity.EnrollmentEnrolled, TransitionRequest{To: entity.EnrollmentSuspended, From: entity.EnrollmentApplicant, Reason: "late"}, ErrStatusChanged, entity.EnrollmentEnrolled, false},
+		{"not allowed", entity.EnrollmentGraduated, TransitionRequest{To: entity.EnrollmentEnrolled, Reason: "back"}, ErrTransitionNotAllowed, entity.EnrollmentGraduated, false},
+		{"unknown status", entity.EnrollmentEnrolled, TransitionRequest{To: "on_leave", Reason: "holiday"}, ErrUnknownStatus, entity.EnrollmentEnrolled, false},
+		{"guard refuses", entity.EnrollmentEnrolled, TransitionRequest{To: entity.EnrollmentGraduated, Reason: "done"}, ErrTransitionNotAllowed, entity.EnrollmentEnrolled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gdb, ctx := newTestDB(t)
+			create(t, gdb, ctx, &entity.Student{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com", EnrollmentStatus: tt.from})
+
+			lifecycle, err := NewLifecycle(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lifecycle.Guard(entity.EnrollmentGraduated, func(context.Context, *entity.Student, string) error {
+				return errNoDiploma
+			})
+			hooked := false
+			lifecycle.OnEnter(entity.EnrollmentWithdrawn, func(_ context.Context, student *entity.Student, transition *entity.StudentTransition) error {
+				hooked = student.EnrollmentStatus == entity.EnrollmentWithdrawn && transition.FromStatus == tt.from
+				return errors.New("hook errors are only logged")
+			})
+			s := NewStudentService(repository.New(gdb), cache.NewLRU(100), NewCacheStats(), "{seq}", lifecycle)
+
+			transition, err := s.Transition(ctx, 1, tt.req, "registrar")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition error = %v, want %v", err, tt.wantErr)
+			}
+			if hooked != tt.wantHook {
+				t.Errorf("hook ran = %v, want %v", hooked, tt.wantHook)
+			}
+
+			student, err := s.FindByID(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if student.EnrollmentStatus != tt.wantStatus {
+				t.Errorf("status = %q, want %q", student.EnrollmentStatus, tt.wantStatus)
+			}
+
+			history, err := s.Transitions(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil {
+				if len(history) != 0 {
+					t.Errorf("history = %+v, want none", history)
+				}
+				return
+			}
+			if len(history) != 1 || history[0].ID != transition.ID || history[0].FromStatus != tt.from ||
+				history[0].ToStatus != tt.req.To || history[0].Actor != "registrar" || history[0].Reason != tt.req.Reason {
+				t.Errorf("history = %+v, want the transition %+v", history, transition)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+// maxStudentNumber is the length of the student_number column.
+const maxStudentNumber = 32
+
+var (
+	ErrStudentNumberFormat = errors.New("invalid student number format")
+	ErrStudentNumberTaken  = errors.New("a student with this student number already exists")
+)
+
+// numberToken matches a placeholder of a student number format, e.g.
+// "{year}" or "{seq:5}".
+var numberToken = regexp.MustCompile(`\{([a-z]+)(?::(\d+))?\}`)
+
+// validateNumberFormat checks that format has exactly one {seq} and no
+// unknown placeholders.
+func validateNumberFormat(format string) error {
+	matches := numberToken.FindAllStringSubmatch(format, -1)
+	if strings.Count(format, "{") != len(matches) || strings.Count(format, "}") != len(matches) {
+		return fmt.Errorf("%w %q: unbalanced braces", ErrStudentNumberFormat, format)
+	}
+	seqs := 0
+	for _, m := range matches {
+		switch m[1] {
+		case "year", "yy", "tenant":
+			if m[2] != "" {
+				return fmt.Errorf("%w %q: {%s} takes no width", ErrStudentNumberFormat, format, m[1])
+			}
+		case "seq":
+			seqs++
+			if width, _ := strconv.Atoi(m[2]); m[2] != "" && (width < 1 || width > 12) {
+				return fmt.Errorf("%w %q: {seq} width must be 1 to 12", ErrStudentNumberFormat, format)
+			}
+		default:
+			return fmt.Errorf("%w %q: unknown placeholder {%s}", ErrStudentNumberFormat, format, m[1])
+		}
+	}
+	if seqs != 1 {
+		return fmt.Errorf("%w %q: needs exactly one {seq}", ErrStudentNumberFormat, format)
+	}
+	return nil
+}
+
+// studentNumbers hands out student numbers from per-tenant counters.
+type studentNumbers struct {
+	repo   repository.Repository
+	format string
+}
+
+// numberFormat returns the format of the tenant of ctx.
+func (g *studentNumbers) numberFormat(ctx context.Context) string {
+	if t := TenantFrom(ctx); t != nil && t.Settings.StudentNumberFormat != "" {
+		return t.Settings.StudentNumberFormat
+	}
+	return g.format
+}
+
+// prefix fills in every placeholder of format but {seq}. The result names
+// the counter, so the count starts again when e.g. the year changes.
+func (g *studentNumbers) prefix(ctx context.Context, format string, now time.Time) string {
+	slug := ""
+	if t := TenantFrom(ctx); t != nil {
+		slug = strings.ToUpper(t.Slug)
+	}
+	return numberToken.ReplaceAllStringFunc(format, func(token string) string {
+		switch numberToken.FindStringSubmatch(token)[1] {
+		case "year":
+			return strconv.Itoa(now.Year())
+		case "yy":
+			return fmt.Sprintf("%02d", now.Year()%100)
+		case "tenant":
+			return slug
+		}
+		return token
+	})
+}
+
+// next returns an unused student number. Numbers that were assigned by
+// hand are skipped.
+func (g *studentNumbers) next(ctx context.Context) (string, error) {
+	format := g.numberFormat(ctx)
+	if err := validateNumberFormat(format); err != nil {
+		return "", err
+	}
+	prefix := g.prefix(ctx, format, time.Now())
+
+	for {
+		n, err := g.repo.NextNumber(ctx, prefix)
+		if err != nil {
+			return "", err
+		}
+		number := numberToken.ReplaceAllStringFunc(prefix, func(token string) string {
+			width, _ := strconv.Atoi(numberToken.FindStringSubmatch(token)[2])
+			return fmt.Sprintf("%0*d", width, n)
+		})
+		if len(number) > maxStudentNumber {
+			return "", fmt.Errorf("%w %q: numbers longer than %d characters", ErrStudentNumberFormat, format, maxStudentNumber)
+		}
+
+		_, err = g.repo.GetByNumber(ctx, number)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return number, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
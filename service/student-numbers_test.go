@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateNumberFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		valid  bool
+	}{
+		{"{seq}", true},
+		{"S{year}-{seq:5}", true},
+		{"{tenant}{yy}{seq:12}", true},
+		{"S{year}", false},
+		{"{seq}{seq}", false},
+		{"{seq:0}", false},
+		{"{seq:13}", false},
+		{"{year:2}{seq}", false},
+		{"{month}{seq}", false},
+		{"S{seq", false},
+		{"S}{seq}", false},
+		{"{SEQ}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			err := validateNumberFormat(tt.format)
+			if tt.valid && err != nil {
+				t.Errorf("validateNumberFormat(%q) = %v, want nil", tt.format, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrStudentNumberFormat) {
+				t.Errorf("validateNumberFormat(%q) = %v, want ErrStudentNumberFormat", tt.format, err)
+			}
+		})
+	}
+}
@@ -3,49 +3,196 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
 	"time"
 
 	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
 	"github.com/Sarthak-D97/go_stuAPI/repository"
-	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 const (
 	studentKeyPrefix = "student:"
 	studentListKey   = "students_list"
 	cacheTTL         = 10 * time.Minute
+	// negativeCacheTTL is how long a lookup of a missing ID is remembered.
+	negativeCacheTTL = 30 * time.Second
 )
 
 // StudentService interface aligned with Controller calls
 type StudentService interface {
-	Create(student *entity.Student) error
-	FindByID(id uint) (*entity.Student, error)
-	FindAll() ([]entity.Student, error)
-	Update(student *entity.Student) error
-	Delete(id uint) error
+	Create(ctx context.Context, student *entity.Student) error
+	FindByID(ctx context.Context, id uint) (*entity.Student, error)
+	FindAll(ctx context.Context) ([]entity.Student, error)
+	// FindByStatus returns the students in any of the enrollment statuses.
+	FindByStatus(ctx context.Context, statuses []string) ([]entity.Student, error)
+	Update(ctx context.Context, student *entity.Student) error
+	Delete(ctx context.Context, id uint) error
+	// AssignNumbers gives every student of the tenant of ctx that has no
+	// student number one, and returns how many were assigned.
+	AssignNumbers(ctx context.Context) (int, error)
+	// Transition moves the student to another enrollment status, if the
+	// lifecycle allows it, and records who did it and why.
+	Transition(ctx context.Context, id int, req TransitionRequest, actor string) (*entity.StudentTransition, error)
+	// Transitions returns the student's status history, oldest first.
+	Transitions(ctx context.Context, id int) ([]entity.StudentTransition, error)
+	// Duplicates reports pairs of students that are probably the same
+	// person.
+	Duplicates(ctx context.Context) ([]DuplicateCandidate, error)
+	// Merge merges another student into the student id: the survivor
+	// takes over the duplicate's related records and the duplicate's ID
+	// redirects to it.
+	Merge(ctx context.Context, id int, req MergeRequest, actor string) (*entity.Student, error)
+	// MergedInto returns the ID of the student that id was merged into.
+	MergedInto(ctx context.Context, id int) (int, error)
+	// OnMerge registers a hook that moves records kept elsewhere when
+	// students are merged.
+	OnMerge(hook MergeHook)
+}
+
+// TransitionRequest asks to move a student to the status To. From, when
+// set, must be the student's current status, so two people acting on the
+// same record do not overwrite each other's decision.
+type TransitionRequest struct {
+	To     string `json:"to" binding:"required"`
+	From   string `json:"from"`
+	Reason string `json:"reason" binding:"required,max=500"`
 }
 
 type studentService struct {
-	repo repository.Repository // Ensure your repository interface matches these types
-	rdb  *redis.Client
+	repo       repository.Repository // Ensure your repository interface matches these types
+	cache      *versionedCache
+	numbers    *studentNumbers
+	lifecycle  *Lifecycle
+	mergeHooks []MergeHook
 }
 
-// NewStudentService creates a new instance of the service
-func NewStudentService(repo repository.Repository, rdb *redis.Client) StudentService {
+// NewStudentService creates a new instance of the service. numberFormat
+// generates student numbers for tenants that do not set their own;
+// lifecycle governs changes of the enrollment status.
+func NewStudentService(repo repository.Repository, c cache.Cache, stats *CacheStats, numberFormat string, lifecycle *Lifecycle) StudentService {
 	return &studentService{
-		repo: repo,
-		rdb:  rdb,
+		repo:      repo,
+		cache:     newVersionedCache(c, stats),
+		numbers:   &studentNumbers{repo: repo, format: numberFormat},
+		lifecycle: lifecycle,
+	}
+}
+
+// tenantKey prefixes key with the tenant of ctx, e.g. "tenant:3:student:42",
+// so tenants never read each other's cached records.
+func tenantKey(ctx context.Context, key string) string {
+	id, _ := tenant.ID(ctx)
+	return fmt.Sprintf("tenant:%d:%s", id, key)
+}
+
+func studentKey(ctx context.Context, id int) string {
+	return tenantKey(ctx, fmt.Sprintf("%s%d", studentKeyPrefix, id))
+}
+
+// studentCacheTTL is how long the tenant of ctx keeps students cached.
+func studentCacheTTL(ctx context.Context) time.Duration {
+	if t := TenantFrom(ctx); t != nil && t.Settings.StudentCacheTTLSeconds > 0 {
+		return time.Duration(t.Settings.StudentCacheTTLSeconds) * time.Second
+	}
+	return cacheTTL
+}
+
+// invalidate drops the cached student and the cached list. It runs before
+// the write returns so the caller reads its own write.
+func (s *studentService) invalidate(ctx context.Context, id int) {
+	if err := s.cache.bump(ctx, studentKey(ctx, id), tenantKey(ctx, studentListKey)); err != nil {
+		logging.FromContext(ctx).Error("failed to invalidate student cache", slog.Int("student_id", id), "error", err)
+	}
+}
+
+// maxStudentAge bounds how far in the past a date of birth may lie.
+const maxStudentAge = 120
+
+// prepare checks a student before it is saved and fills in the defaults.
+// prev is the stored record on update and nil on create; custom
+// attributes that did not change are not checked again, so a change to
+// the tenant's schema does not block unrelated updates. The enrollment
+// status is set on create and afterwards only changed by Transition.
+func (s *studentService) prepare(ctx context.Context, student *entity.Student, prev *entity.Student) error {
+	switch {
+	case prev != nil && student.EnrollmentStatus == "":
+		student.EnrollmentStatus = prev.EnrollmentStatus
+	case prev != nil && student.EnrollmentStatus != prev.EnrollmentStatus:
+		return fmt.Errorf("%w: %w", ErrInvalidStudent, ErrStatusNotEditable)
+	case student.EnrollmentStatus == "":
+		student.EnrollmentStatus = entity.EnrollmentEnrolled
+	}
+
+	if dob := student.DateOfBirth; dob != nil {
+		now := time.Now()
+		if dob.After(now) {
+			return fmt.Errorf("%w: date_of_birth must be in the past", ErrInvalidStudent)
+		}
+		if dob.YearsOn(now) > maxStudentAge {
+			return fmt.Errorf("%w: date_of_birth is more than %d years ago", ErrInvalidStudent, maxStudentAge)
+		}
+	} else if prev == nil || prev.DateOfBirth != nil {
+		return fmt.Errorf("%w: date_of_birth is required", ErrInvalidStudent)
+	}
+	// Only a date carried over from the age migration stays estimated.
+	student.BirthDateEstimated = prev != nil && prev.BirthDateEstimated &&
+		student.DateOfBirth != nil && prev.DateOfBirth != nil && student.DateOfBirth.Equal(prev.DateOfBirth.Time)
+
+	if prev == nil || !reflect.DeepEqual(student.CustomAttributes, prev.CustomAttributes) {
+		var defs []entity.StudentAttribute
+		if t := TenantFrom(ctx); t != nil {
+			defs = t.Settings.StudentAttributes
+		}
+		if err := validateAttributes(defs, student.CustomAttributes); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case student.StudentNumber == "" && prev != nil && prev.StudentNumber != "":
+		student.StudentNumber = prev.StudentNumber
+	case student.StudentNumber == "":
+		number, err := s.numbers.next(ctx)
+		if err != nil {
+			return err
+		}
+		student.StudentNumber = number
+	case prev == nil || student.StudentNumber != prev.StudentNumber:
+		existing, err := s.repo.GetByNumber(ctx, student.StudentNumber)
+		if err == nil && existing.ID != student.ID {
+			return ErrStudentNumberTaken
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
 	}
+	return nil
 }
 
 // Create - Aligned to receive pointer
-func (s *studentService) Create(student *entity.Student) error {
+func (s *studentService) Create(ctx context.Context, student *entity.Student) error {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.Create")
+	defer span.End()
+
+	if err := s.prepare(ctx, student, nil); err != nil {
+		return err
+	}
+
 	// 1. Save to DB
 	// We pass the pointer or value depending on your repo implementation.
 	// Assuming Repo returns the created struct with ID.
-	created, err := s.repo.Create(*student)
+	created, err := s.repo.Create(ctx, *student)
 	if err != nil {
 		return err
 	}
@@ -53,138 +200,208 @@ func (s *studentService) Create(student *entity.Student) error {
 	// Update the original pointer with the new ID so the controller can return it
 	student.ID = created.ID
 
-	// 2. Cache (Async)
-	go func(st entity.Student) {
-		ctx := context.Background()
-		cacheKey := fmt.Sprintf("%s%d", studentKeyPrefix, st.ID)
+	// 2. Invalidate (also clears a negative entry for the new ID)
+	s.invalidate(ctx, created.ID)
 
-		pipe := s.rdb.Pipeline()
-		// Use JSON for HSet if fields aren't flat, or use standard Set for simplicity
-		// Here we stick to your HSet logic, ensure struct has redis tags
-		pipe.HSet(ctx, cacheKey, st)
-		pipe.Expire(ctx, cacheKey, cacheTTL)
-
-		// Invalidate list cache
-		pipe.Del(ctx, studentListKey)
-
-		if _, err := pipe.Exec(ctx); err != nil {
-			slog.Error("failed to cache student", "error", err)
-		}
-	}(created)
-
-	slog.Info("student created successfully", slog.Uint64("student_id", uint64(created.ID)))
+	logging.FromContext(ctx).Info("student created successfully", slog.Uint64("student_id", uint64(created.ID)))
 	return nil
 }
 
 // FindByID - Aligned to accept uint
-func (s *studentService) FindByID(id uint) (*entity.Student, error) {
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("%s%d", studentKeyPrefix, id)
-
-	// 1. Check Redis
-	var cachedStudent entity.Student
-	// Using HGetAll assuming the data was stored as a Hash
-	if err := s.rdb.HGetAll(ctx, cacheKey).Scan(&cachedStudent); err == nil && cachedStudent.ID != 0 {
-		slog.Info("serving student from cache (hash)", slog.Uint64("id", uint64(id)))
-		return &cachedStudent, nil
-	}
-
-	// 2. Check DB
-	// Cast uint to int64 if your repo expects int64
-	student, err := s.repo.GetByID(int64(id))
+func (s *studentService) FindByID(ctx context.Context, id uint) (*entity.Student, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.FindByID",
+		trace.WithAttributes(attribute.Int64("student.id", int64(id))))
+	defer span.End()
+
+	data, hit, err := s.cache.load(ctx, cacheFamilyStudent, studentKey(ctx, int(id)), func(ctx context.Context) ([]byte, time.Duration, error) {
+		student, err := s.repo.GetByID(ctx, int64(id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Cache the miss as JSON null.
+			return []byte("null"), negativeCacheTTL, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		data, err := json.Marshal(student)
+		return data, studentCacheTTL(ctx), err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Cache (Async)
-	go func(st *entity.Student, key string) {
-		ctx := context.Background()
-		pipe := s.rdb.Pipeline()
-		pipe.HSet(ctx, key, st)
-		pipe.Expire(ctx, key, cacheTTL)
-		if _, err := pipe.Exec(ctx); err != nil {
-			slog.Error("failed to cache student", "error", err)
-		}
-	}(student, cacheKey)
+	var student *entity.Student
+	if err := json.Unmarshal(data, &student); err != nil {
+		return nil, err
+	}
+	if student == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
 
-	slog.Info("student fetched successfully", slog.Uint64("student_id", uint64(id)))
+	if hit {
+		logging.FromContext(ctx).Info("serving student from cache", slog.Uint64("id", uint64(id)))
+	} else {
+		logging.FromContext(ctx).Info("student fetched successfully", slog.Uint64("student_id", uint64(id)))
+	}
 	return student, nil
 }
 
 // FindAll - Renamed from GetAllStudents
-func (s *studentService) FindAll() ([]entity.Student, error) {
-	ctx := context.Background()
-
-	// 1. Check Redis
-	val, err := s.rdb.Get(ctx, studentListKey).Result()
-	if err == nil {
-		var cachedStudents []entity.Student
-		if jsonErr := json.Unmarshal([]byte(val), &cachedStudents); jsonErr == nil {
-			slog.Info("serving student list from cache")
-			return cachedStudents, nil
+func (s *studentService) FindAll(ctx context.Context) ([]entity.Student, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.FindAll")
+	defer span.End()
+
+	data, hit, err := s.cache.load(ctx, cacheFamilyStudentList, tenantKey(ctx, studentListKey), func(ctx context.Context) ([]byte, time.Duration, error) {
+		students, err := s.repo.List(ctx)
+		if err != nil {
+			return nil, 0, err
 		}
+		data, err := json.Marshal(students)
+		return data, studentCacheTTL(ctx), err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. Check DB
-	students, err := s.repo.List()
-	if err != nil {
+	var students []entity.Student
+	if err := json.Unmarshal(data, &students); err != nil {
 		return nil, err
 	}
 
-	// 3. Cache (Async)
-	go func(st []entity.Student) {
-		data, _ := json.Marshal(st)
-		s.rdb.Set(context.Background(), studentListKey, data, cacheTTL)
-	}(students)
+	if hit {
+		logging.FromContext(ctx).Info("serving student list from cache")
+	} else {
+		logging.FromContext(ctx).Info("students fetched successfully", slog.Int("count", len(students)))
+	}
+	return students, nil
+}
 
-	slog.Info("students fetched successfully", slog.Int("count", len(students)))
+func (s *studentService) FindByStatus(ctx context.Context, statuses []string) ([]entity.Student, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.FindByStatus")
+	defer span.End()
+
+	for _, status := range statuses {
+		if !slices.Contains(EnrollmentStatuses, status) {
+			return nil, fmt.Errorf("%w: %w %q", ErrInvalidStudent, ErrUnknownStatus, status)
+		}
+	}
+	students, err := s.repo.ListByStatus(ctx, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("students fetched successfully", slog.Int("count", len(students)), slog.Any("statuses", statuses))
 	return students, nil
 }
 
 // Update - Aligned to accept pointer
-func (s *studentService) Update(student *entity.Student) error {
-	// Cast ID to int64 for repo
-	if err := s.repo.Update(int64(student.ID), *student); err != nil {
+func (s *studentService) Update(ctx context.Context, student *entity.Student) error {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.Update",
+		trace.WithAttributes(attribute.Int("student.id", student.ID)))
+	defer span.End()
+
+	prev, err := s.repo.GetByID(ctx, int64(student.ID))
+	if err != nil {
+		return err
+	}
+	if err := s.prepare(ctx, student, prev); err != nil {
 		return err
 	}
 
-	// Invalidate/Update Cache (Async)
-	go func(st entity.Student) {
-		ctx := context.Background()
-		cacheKey := fmt.Sprintf("%s%d", studentKeyPrefix, st.ID)
-
-		pipe := s.rdb.Pipeline()
-		pipe.HSet(ctx, cacheKey, st) // Update individual cache
-		pipe.Expire(ctx, cacheKey, cacheTTL)
-		pipe.Del(ctx, studentListKey) // Invalidate list cache
+	// Cast ID to int64 for repo
+	if err := s.repo.Update(ctx, int64(student.ID), *student); err != nil {
+		return err
+	}
 
-		if _, err := pipe.Exec(ctx); err != nil {
-			slog.Error("failed to update student cache", "error", err)
-		}
-	}(*student)
+	s.invalidate(ctx, student.ID)
 
-	slog.Info("student updated successfully", slog.Uint64("student_id", uint64(student.ID)))
+	logging.FromContext(ctx).Info("student updated successfully", slog.Uint64("student_id", uint64(student.ID)))
 	return nil
 }
 
 // Delete - Aligned to accept uint
-func (s *studentService) Delete(id uint) error {
-	if err := s.repo.Delete(int64(id)); err != nil {
+func (s *studentService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.Delete",
+		trace.WithAttributes(attribute.Int64("student.id", int64(id))))
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, int64(id)); err != nil {
 		return err
 	}
 
-	// Clear Cache (Async)
-	go func(uid uint) {
-		ctx := context.Background()
-		pipe := s.rdb.Pipeline()
-		pipe.Del(ctx, fmt.Sprintf("%s%d", studentKeyPrefix, uid))
-		pipe.Del(ctx, studentListKey)
-
-		if _, err := pipe.Exec(ctx); err != nil {
-			slog.Error("failed to update student cache after delete", "error", err)
-		}
-	}(id)
+	s.invalidate(ctx, int(id))
 
-	slog.Info("student deleted successfully", slog.Uint64("student_id", uint64(id)))
+	logging.FromContext(ctx).Info("student deleted successfully", slog.Uint64("student_id", uint64(id)))
 	return nil
 }
+
+func (s *studentService) Transition(ctx context.Context, id int, req TransitionRequest, actor string) (*entity.StudentTransition, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "StudentService.Transition",
+		trace.WithAttributes(attribute.Int("student.id", id), attribute.String("student.status", req.To)))
+	defer span.End()
+
+	student, err := s.repo.GetByID(ctx, int64(id))
+	if err != nil {
+		return nil, err
+	}
+	if req.From != "" && req.From != student.EnrollmentStatus {
+		return nil, ErrStatusChanged
+	}
+	if err := s.lifecycle.check(ctx, student, req.To); err != nil {
+		return nil, err
+	}
+
+	transition := &entity.StudentTransition{
+		StudentID:  id,
+		FromStatus: student.EnrollmentStatus,
+		ToStatus:   req.To,
+		Reason:     req.Reason,
+		Actor:      actor,
+	}
+	applied, err := s.repo.Transition(ctx, transition)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, ErrStatusChanged
+	}
+	s.invalidate(ctx, id)
+
+	logging.FromContext(ctx).Info("student status changed",
+		slog.Int("student_id", id),
+		slog.String("from", transition.FromStatus),
+		slog.String("to", transition.ToStatus),
+		slog.String("actor", actor),
+	)
+	student.EnrollmentStatus = req.To
+	s.lifecycle.entered(ctx, student, transition)
+	return transition, nil
+}
+
+func (s *studentService) Transitions(ctx context.Context, id int) ([]entity.StudentTransition, error) {
+	if _, err := s.repo.GetByID(ctx, int64(id)); err != nil {
+		return nil, err
+	}
+	return s.repo.Transitions(ctx, id)
+}
+
+func (s *studentService) AssignNumbers(ctx context.Context) (int, error) {
+	students, err := s.repo.ListWithoutNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, student := range students {
+		number, err := s.numbers.next(ctx)
+		if err != nil {
+			return i, err
+		}
+		student.StudentNumber = number
+		if err := s.repo.Update(ctx, int64(student.ID), student); err != nil {
+			return i, err
+		}
+		s.invalidate(ctx, student.ID)
+	}
+	if len(students) > 0 {
+		logging.FromContext(ctx).Info("student numbers assigned", slog.Int("count", len(students)))
+	}
+	return len(students), nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+)
+
+var ErrCategoryInUse = errors.New("category still has subcategories or videos")
+
+type TaxonomyService interface {
+	ListTags(ctx context.Context) ([]entity.Tag, error)
+	ResolveTags(ctx context.Context, tags []entity.Tag) ([]entity.Tag, error)
+	CreateCategory(ctx context.Context, category *entity.Category) error
+	FindCategory(ctx context.Context, id uint64) (*entity.Category, error)
+	CategoryTree(ctx context.Context) ([]entity.Category, error)
+	// Subtree returns id followed by the IDs of all its descendants.
+	Subtree(ctx context.Context, id uint64) ([]uint64, error)
+	DeleteCategory(ctx context.Context, id uint64) error
+}
+
+type taxonomyService struct {
+	repo repository.TaxonomyRepository
+}
+
+func NewTaxonomyService(repo repository.TaxonomyRepository) TaxonomyService {
+	return &taxonomyService{repo: repo}
+}
+
+// NormalizeTags lower-cases, trims and de-duplicates tag names.
+func NormalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *taxonomyService) ListTags(ctx context.Context) ([]entity.Tag, error) {
+	return s.repo.ListTags(ctx)
+}
+
+// ResolveTags maps the tag names given on a video onto stored tags.
+func (s *taxonomyService) ResolveTags(ctx context.Context, tags []entity.Tag) ([]entity.Tag, error) {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return s.repo.FindOrCreateTags(ctx, NormalizeTags(names))
+}
+
+func (s *taxonomyService) CreateCategory(ctx context.Context, category *entity.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.ParentID != nil {
+		if _, err := s.repo.GetCategory(ctx, *category.ParentID); err != nil {
+			return err
+		}
+	}
+
+	created, err := s.repo.CreateCategory(ctx, *category)
+	if err != nil {
+		return err
+	}
+	category.ID = created.ID
+
+	logging.FromContext(ctx).Info("category created successfully", slog.Uint64("category_id", created.ID))
+	return nil
+}
+
+func (s *taxonomyService) FindCategory(ctx context.Context, id uint64) (*entity.Category, error) {
+	return s.repo.GetCategory(ctx, id)
+}
+
+// CategoryTree returns the root categories with their descendants nested.
+func (s *taxonomyService) CategoryTree(ctx context.Context) ([]entity.Category, error) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint64][]entity.Category)
+	var roots []entity.Category
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var attach func(nodes []entity.Category) []entity.Category
+	attach = func(nodes []entity.Category) []entity.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots), nil
+}
+
+func (s *taxonomyService) Subtree(ctx context.Context, id uint64) ([]uint64, error) {
+	if _, err := s.repo.GetCategory(ctx, id); err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint64][]uint64)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	ids := []uint64{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+func (s *taxonomyService) DeleteCategory(ctx context.Context, id uint64) error {
+	if _, err := s.repo.GetCategory(ctx, id); err != nil {
+		return err
+	}
+
+	children, videos, err := s.repo.CountCategoryUsage(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 || videos > 0 {
+		return ErrCategoryInUse
+	}
+
+	if err := s.repo.DeleteCategory(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("category deleted successfully", slog.Uint64("category_id", id))
+	return nil
+}
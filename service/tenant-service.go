@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/logging"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tenant"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+// tenantCacheTTL is how long a replica keeps serving a tenant it looked
+// up, so a settings change reaches every replica within this time.
+const tenantCacheTTL = 30 * time.Second
+
+var (
+	ErrUnknownTenant   = errors.New("unknown tenant")
+	ErrTenantSlug      = errors.New("slug must be 1-63 lower-case letters, digits or dashes, not starting or ending with a dash")
+	ErrTenantSlugTaken = errors.New("slug is already taken")
+	ErrTenantSettings  = errors.New("invalid tenant settings")
+)
+
+// tenantSlugPattern accepts slugs that work as a DNS label.
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx scoped to t: repositories only see t's
+// rows and services read t's settings.
+func WithTenant(ctx context.Context, t *entity.Tenant) context.Context {
+	ctx = context.WithValue(ctx, tenantContextKey{}, t)
+	return tenant.WithID(ctx, t.ID)
+}
+
+// TenantFrom returns the tenant ctx was scoped to with WithTenant, or nil.
+func TenantFrom(ctx context.Context) *entity.Tenant {
+	t, _ := ctx.Value(tenantContextKey{}).(*entity.Tenant)
+	return t
+}
+
+// MFARequiredRoles returns the roles that must log in with MFA in the
+// tenant of ctx: the tenant's own list when it has one, else fallback.
+func MFARequiredRoles(ctx context.Context, fallback []string) []string {
+	if t := TenantFrom(ctx); t != nil && t.Settings.MFARequiredRoles != nil {
+		return t.Settings.MFARequiredRoles
+	}
+	return fallback
+}
+
+// TenantRequest creates or updates a tenant. Slug cannot be changed later.
+type TenantRequest struct {
+	Slug     string                `json:"slug"`
+	Name     string                `json:"name" binding:"required,max=255"`
+	Settings entity.TenantSettings `json:"settings"`
+}
+
+// TenantService manages tenants and looks them up for every request, so
+// lookups are cached for a short while.
+type TenantService interface {
+	// EnsureDefault creates the default tenant on first start.
+	EnsureDefault(ctx context.Context) (*entity.Tenant, error)
+	Default(ctx context.Context) (*entity.Tenant, error)
+	BySlug(ctx context.Context, slug string) (*entity.Tenant, error)
+	List(ctx context.Context) ([]entity.Tenant, error)
+	Create(ctx context.Context, req TenantRequest) (entity.Tenant, error)
+	Update(ctx context.Context, id uint64, req TenantRequest) (entity.Tenant, error)
+	// SetStudentAttributes replaces the custom student attributes of the
+	// tenant of ctx and leaves its other settings alone.
+	SetStudentAttributes(ctx context.Context, defs []entity.StudentAttribute) ([]entity.StudentAttribute, error)
+}
+
+// validateTenantSettings rejects settings the rest of the service could
+// not use.
+func validateTenantSettings(settings entity.TenantSettings) error {
+	for _, role := range settings.MFARequiredRoles {
+		if role != RoleAdmin && role != RoleUser && role != RoleStudent && role != RoleGuardian {
+			return fmt.Errorf("%w: unknown role %q in mfa_required_roles", ErrTenantSettings, role)
+		}
+	}
+	if settings.BaseURL != "" {
+		u, err := url.Parse(settings.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: base_url must be an absolute http(s) URL", ErrTenantSettings)
+		}
+	}
+	if settings.StudentCacheTTLSeconds < 0 {
+		return fmt.Errorf("%w: student_cache_ttl_seconds must not be negative", ErrTenantSettings)
+	}
+	if settings.StudentNumberFormat != "" {
+		if err := validateNumberFormat(settings.StudentNumberFormat); err != nil {
+			return fmt.Errorf("%w: %s", ErrTenantSettings, err.Error())
+		}
+	}
+	return validateAttributeDefinitions(settings.StudentAttributes)
+}
+
+type cachedTenant struct {
+	tenant  *entity.Tenant
+	expires time.Time
+}
+
+type tenantService struct {
+	repo        repository.TenantRepository
+	defaultSlug string
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+func NewTenantService(repo repository.TenantRepository, defaultSlug string) TenantService {
+	return &tenantService{
+		repo:        repo,
+		defaultSlug: defaultSlug,
+		cache:       map[string]cachedTenant{},
+	}
+}
+
+func (s *tenantService) EnsureDefault(ctx context.Context) (*entity.Tenant, error) {
+	t, err := s.BySlug(ctx, s.defaultSlug)
+	if !errors.Is(err, ErrUnknownTenant) {
+		return t, err
+	}
+	created, err := s.Create(ctx, TenantRequest{Slug: s.defaultSlug, Name: s.defaultSlug})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s *tenantService) Default(ctx context.Context) (*entity.Tenant, error) {
+	return s.BySlug(ctx, s.defaultSlug)
+}
+
+func (s *tenantService) BySlug(ctx context.Context, slug string) (*entity.Tenant, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[slug]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.tenant, nil
+	}
+
+	t, err := s.repo.GetBySlug(ctx, slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownTenant
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.remember(t)
+	return t, nil
+}
+
+func (s *tenantService) remember(t *entity.Tenant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[t.Slug] = cachedTenant{tenant: t, expires: time.Now().Add(tenantCacheTTL)}
+}
+
+func (s *tenantService) List(ctx context.Context) ([]entity.Tenant, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *tenantService) Create(ctx context.Context, req TenantRequest) (entity.Tenant, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !tenantSlugPattern.MatchString(slug) {
+		return entity.Tenant{}, ErrTenantSlug
+	}
+	if err := validateTenantSettings(req.Settings); err != nil {
+		return entity.Tenant{}, err
+	}
+	if _, err := s.repo.GetBySlug(ctx, slug); err == nil {
+		return entity.Tenant{}, ErrTenantSlugTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.Tenant{}, err
+	}
+
+	created, err := s.repo.Create(ctx, entity.Tenant{
+		Slug:     slug,
+		Name:     strings.TrimSpace(req.Name),
+		Settings: req.Settings,
+	})
+	if err != nil {
+		return entity.Tenant{}, err
+	}
+	s.remember(&created)
+	logging.FromContext(ctx).Info("tenant created", slog.Uint64("tenant_id", created.ID), slog.String("slug", slug))
+	return created, nil
+}
+
+func (s *tenantService) Update(ctx context.Context, id uint64, req TenantRequest) (entity.Tenant, error) {
+	if err := validateTenantSettings(req.Settings); err != nil {
+		return entity.Tenant{}, err
+	}
+	t, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.Tenant{}, ErrUnknownTenant
+	}
+	if err != nil {
+		return entity.Tenant{}, err
+	}
+	t.Name = strings.TrimSpace(req.Name)
+	t.Settings = req.Settings
+	if err := s.repo.Update(ctx, *t); err != nil {
+		return entity.Tenant{}, err
+	}
+	s.remember(t)
+	logging.FromContext(ctx).Info("tenant updated", slog.Uint64("tenant_id", t.ID), slog.String("slug", t.Slug))
+	return *t, nil
+}
+
+func (s *tenantService) SetStudentAttributes(ctx context.Context, defs []entity.StudentAttribute) ([]entity.StudentAttribute, error) {
+	current := TenantFrom(ctx)
+	if current == nil {
+		return nil, ErrUnknownTenant
+	}
+	if err := validateAttributeDefinitions(defs); err != nil {
+		return nil, err
+	}
+	t, err := s.repo.GetByID(ctx, current.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownTenant
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Settings.StudentAttributes = defs
+	if err := s.repo.Update(ctx, *t); err != nil {
+		return nil, err
+	}
+	s.remember(t)
+	logging.FromContext(ctx).Info("student attributes updated", slog.Uint64("tenant_id", t.ID), slog.Int("count", len(defs)))
+	return defs, nil
+}
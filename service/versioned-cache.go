@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// versionTTL outlives any data entry so a version is rarely recreated.
+	versionTTL = 2 * cacheTTL
+	// ttlJitter spreads expiries of entries filled together by up to 10%.
+	ttlJitter = 0.1
+)
+
+// versionedCache stores each key family (e.g. "student:42") under a random
+// version token that writers replace on every change. Loaders read the
+// version before the database, so a fill that raced with a write lands
+// under the superseded version and is never served. Concurrent misses for
+// the same version are coalesced into a single load.
+type versionedCache struct {
+	cache cache.Cache
+	stats *CacheStats
+	group singleflight.Group
+}
+
+func newVersionedCache(c cache.Cache, stats *CacheStats) *versionedCache {
+	return &versionedCache{cache: c, stats: stats}
+}
+
+func versionKey(family string) string {
+	return family + ":version"
+}
+
+func dataKey(family, version string) string {
+	return family + ":v:" + version
+}
+
+func newVersion() string {
+	return strconv.FormatUint(rand.Uint64(), 36)
+}
+
+func jitter(ttl time.Duration) time.Duration {
+	return ttl + time.Duration(rand.Float64()*ttlJitter*float64(ttl))
+}
+
+// sharedContext returns the context for a load shared by several callers.
+// One caller going away must not fail the others, so it ignores ctx's
+// cancellation but keeps its deadline.
+func sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	shared := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(shared, deadline)
+	}
+	return shared, func() {}
+}
+
+// version returns the family's current version, starting a new one when
+// none is cached.
+func (v *versionedCache) version(ctx context.Context, family string) (string, error) {
+	if b, err := v.cache.Get(ctx, versionKey(family)); err == nil {
+		return string(b), nil
+	}
+	version := newVersion()
+	return version, v.cache.Set(ctx, versionKey(family), []byte(version), versionTTL)
+}
+
+// bump invalidates every entry of the given families. A version that
+// could not be replaced is deleted instead, so the next load starts a new
+// one rather than reading the old version's entries.
+func (v *versionedCache) bump(ctx context.Context, families ...string) error {
+	var errs []error
+	for _, family := range families {
+		key := versionKey(family)
+		if err := v.cache.Set(ctx, key, []byte(newVersion()), versionTTL); err != nil {
+			if delErr := v.cache.Delete(ctx, key); delErr != nil {
+				errs = append(errs, err, delErr)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// load returns the cached value of family, calling fetch on a miss. fetch
+// returns the value to cache and its TTL; a zero TTL skips caching. hit
+// reports whether the value came from the cache. Outcomes are counted in
+// stats under kind and recorded on a "cache.load" span. A caller whose ctx
+// ends stops waiting even if the shared load carries on.
+func (v *versionedCache) load(
+	ctx context.Context,
+	kind string,
+	family string,
+	fetch func(ctx context.Context) ([]byte, time.Duration, error),
+) (value []byte, hit bool, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cache.load", trace.WithAttributes(
+		attribute.String("cache.family", kind),
+		attribute.String("cache.key", family),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", hit))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	version, verErr := v.version(ctx, family)
+	if verErr != nil {
+		// Without a trustworthy version the cache cannot be used safely.
+		v.stats.miss(kind)
+		value, _, err = fetch(ctx)
+		return value, false, err
+	}
+
+	key := dataKey(family, version)
+	if b, err := v.cache.Get(ctx, key); err == nil {
+		v.stats.hit(kind)
+		return b, true, nil
+	}
+	v.stats.miss(kind)
+
+	ch := v.group.DoChan(key, func() (any, error) {
+		ctx, cancel := sharedContext(ctx)
+		defer cancel()
+
+		value, ttl, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			if err := v.cache.Set(ctx, key, value, jitter(ttl)); err != nil {
+				v.stats.fillError(kind)
+			} else {
+				v.stats.fill(kind)
+			}
+		}
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+		return res.Val.([]byte), false, nil
+	}
+}
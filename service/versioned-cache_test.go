@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/platform/cache"
+	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+var errCacheDown = errors.New("cache down")
+
+// brokenCache is an in-memory cache whose writes and deletes can be made
+// to fail.
+type brokenCache struct {
+	cache.Cache
+	failSet    bool
+	failDelete bool
+}
+
+func (c *brokenCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c.failSet {
+		return errCacheDown
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func (c *brokenCache) Delete(ctx context.Context, keys ...string) error {
+	if c.failDelete {
+		return errCacheDown
+	}
+	return c.Cache.Delete(ctx, keys...)
+}
+
+func TestVersionedCacheBump(t *testing.T) {
+	tests := []struct {
+		name       string
+		failSet    bool
+		failDelete bool
+		wantErr    bool
+		wantReload bool
+	}{
+		{"new version", false, false, false, true},
+		{"write fails, version deleted", true, false, false, true},
+		{"write and delete fail", true, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := &brokenCache{Cache: cache.NewLRU(100)}
+			v := newVersionedCache(c, NewCacheStats())
+
+			fetches := 0
+			fetch := func(context.Context) ([]byte, time.Duration, error) {
+				fetches++
+				return []byte("value"), time.Hour, nil
+			}
+			for range 2 {
+				if _, _, err := v.load(ctx, "test", "family", fetch); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if fetches != 1 {
+				t.Fatalf("fetched %d times before the bump, want 1", fetches)
+			}
+
+			c.failSet, c.failDelete = tt.failSet, tt.failDelete
+			if err := v.bump(ctx, "family"); (err != nil) != tt.wantErr {
+				t.Fatalf("bump error = %v, want error %v", err, tt.wantErr)
+			}
+			c.failSet, c.failDelete = false, false
+
+			_, hit, err := v.load(ctx, "test", "family", fetch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hit == tt.wantReload {
+				t.Errorf("load after the bump hit the cache = %v, want %v", hit, !tt.wantReload)
+			}
+		})
+	}
+}
+
+// studentRepo serves students from a map and counts lookups. Methods the
+// tests do not use are left to the embedded nil interface.
+type studentRepo struct {
+	repository.Repository
+	students map[int64]entity.Student
+	gets     int
+}
+
+func (r *studentRepo) GetByID(_ context.Context, id int64) (*entity.Student, error) {
+	r.gets++
+	student, ok := r.students[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &student, nil
+}
+
+func TestFindByIDNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	lifecycle, err := NewLifecycle(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &studentRepo{students: map[int64]entity.Student{}}
+	s := NewStudentService(repo, cache.NewLRU(100), NewCacheStats(), "{seq}", lifecycle).(*studentService)
+
+	steps := []struct {
+		name     string
+		before   func()
+		wantErr  error
+		wantGets int
+	}{
+		{"missing student is looked up", nil, gorm.ErrRecordNotFound, 1},
+		{"miss is served from the cache", nil, gorm.ErrRecordNotFound, 1},
+		{"invalidation drops the miss", func() {
+			repo.students[7] = entity.Student{ID: 7, Name: "Ada Lovelace"}
+			s.invalidate(ctx, 7)
+		}, nil, 2},
+		{"student is served from the cache", nil, nil, 2},
+	}
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		student, err := s.FindByID(ctx, 7)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if err == nil && student.Name != "Ada Lovelace" {
+			t.Errorf("%s: student = %+v", step.name, student)
+		}
+		if repo.gets != step.wantGets {
+			t.Errorf("%s: repository read %d times, want %d", step.name, repo.gets, step.wantGets)
+		}
+	}
+}
@@ -1,37 +1,220 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/Sarthak-D97/go_stuAPI/entity"
+	"github.com/Sarthak-D97/go_stuAPI/internal/utils/canonicalurl"
 	"github.com/Sarthak-D97/go_stuAPI/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
+// DuplicateURLError is returned when another video already has the same
+// canonical URL.
+type DuplicateURLError struct {
+	Existing entity.Video
+}
+
+func (e *DuplicateURLError) Error() string {
+	return fmt.Sprintf("a video with this URL already exists (id %d)", e.Existing.ID)
+}
+
 type VideoService interface {
-	Save(entity.Video) entity.Video
-	Update(video entity.Video)
-	Delete(video entity.Video)
-	FindAll() ([]entity.Video, error)
+	Save(ctx context.Context, video entity.Video) (entity.Video, error)
+	Update(ctx context.Context, video entity.Video) error
+	// Upsert creates the video, or updates the one already stored under the
+	// same canonical URL. created reports which of the two happened.
+	Upsert(ctx context.Context, video entity.Video) (saved entity.Video, created bool, err error)
+	Delete(ctx context.Context, video entity.Video)
+	FindAll(ctx context.Context) ([]entity.Video, error)
+	FindByID(ctx context.Context, id uint64) (*entity.Video, error)
+	Search(ctx context.Context, filter repository.VideoFilter) (repository.VideoSearchResult, error)
 }
 
 type videoService struct {
 	videoRepository repository.VideoRepository
+	personService   PersonService
+	taxonomyService TaxonomyService
 }
 
-func NewVideoService(repo repository.VideoRepository) VideoService {
+func NewVideoService(repo repository.VideoRepository, personService PersonService, taxonomyService TaxonomyService) VideoService {
 	return &videoService{
 		videoRepository: repo,
+		personService:   personService,
+		taxonomyService: taxonomyService,
+	}
+}
+
+// resolveAuthor points the video at a stored person so that the same author
+// is never inserted twice.
+func (s *videoService) resolveAuthor(ctx context.Context, video *entity.Video) error {
+	author, err := s.personService.Resolve(ctx, video.PersonID, video.Author)
+	if err != nil {
+		return err
+	}
+	video.Author = author
+	video.PersonID = author.ID
+	return nil
+}
+
+// resolveTaxonomy swaps tag names for stored tags and checks the category.
+func (s *videoService) resolveTaxonomy(ctx context.Context, video *entity.Video) error {
+	tags, err := s.taxonomyService.ResolveTags(ctx, video.Tags)
+	if err != nil {
+		return err
+	}
+	video.Tags = tags
+
+	video.Category = nil
+	if video.CategoryID != nil {
+		if _, err := s.taxonomyService.FindCategory(ctx, *video.CategoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *videoService) resolve(ctx context.Context, video *entity.Video) error {
+	if err := s.resolveAuthor(ctx, video); err != nil {
+		return err
+	}
+	return s.resolveTaxonomy(ctx, video)
+}
+
+// findDuplicate canonicalizes video.URL in place and returns the other video
+// already stored under it, if any. The raw URL is matched too so rows saved
+// before canonicalization are still found.
+func (s *videoService) findDuplicate(ctx context.Context, video *entity.Video) (*entity.Video, error) {
+	raw := video.URL
+	canonical, err := canonicalurl.Canonicalize(raw)
+	if err != nil {
+		return nil, err
+	}
+	video.URL = canonical
+
+	existing, err := s.videoRepository.FindByURL(ctx, canonical, raw)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.ID == video.ID {
+		return nil, nil
+	}
+	return existing, nil
+}
+
+func (s *videoService) Update(ctx context.Context, video entity.Video) error {
+	existing, err := s.findDuplicate(ctx, &video)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return &DuplicateURLError{Existing: *existing}
 	}
+	if err := s.resolve(ctx, &video); err != nil {
+		return err
+	}
+	return s.videoRepository.Update(ctx, video)
 }
-func (s *videoService) Update(video entity.Video) {
-	s.videoRepository.Update(video)
+func (s *videoService) Delete(ctx context.Context, video entity.Video) {
+	s.videoRepository.Delete(ctx, video)
 }
-func (s *videoService) Delete(video entity.Video) {
-	s.videoRepository.Delete(video)
+
+func (s *videoService) Save(ctx context.Context, video entity.Video) (entity.Video, error) {
+	video.ID = 0
+	video.RatingAverage = 0
+	video.RatingCount = 0
+
+	existing, err := s.findDuplicate(ctx, &video)
+	if err != nil {
+		return video, err
+	}
+	if existing != nil {
+		return video, &DuplicateURLError{Existing: *existing}
+	}
+	if err := s.resolve(ctx, &video); err != nil {
+		return video, err
+	}
+
+	saved, err := s.videoRepository.Save(ctx, video)
+	if err != nil {
+		// Lost a race against a concurrent insert of the same URL.
+		if existing, findErr := s.videoRepository.FindByURL(ctx, video.URL); findErr == nil {
+			return video, &DuplicateURLError{Existing: *existing}
+		}
+		return video, err
+	}
+	return saved, nil
 }
 
-func (s *videoService) Save(video entity.Video) entity.Video {
-	s.videoRepository.Save(video)
-	return video
+func (s *videoService) Upsert(ctx context.Context, video entity.Video) (entity.Video, bool, error) {
+	video.ID = 0
+	existing, err := s.findDuplicate(ctx, &video)
+	if err != nil {
+		return video, false, err
+	}
+	if existing == nil {
+		saved, err := s.Save(ctx, video)
+		return saved, err == nil, err
+	}
+
+	video.ID = existing.ID
+	video.CreatedAt = existing.CreatedAt
+	video.RatingAverage = existing.RatingAverage
+	video.RatingCount = existing.RatingCount
+	if err := s.resolve(ctx, &video); err != nil {
+		return video, false, err
+	}
+	if err := s.videoRepository.Update(ctx, video); err != nil {
+		return video, false, err
+	}
+	updated, err := s.videoRepository.FindByID(ctx, video.ID)
+	if err != nil {
+		return video, false, err
+	}
+	return *updated, false, nil
+}
+
+func (s *videoService) FindAll(ctx context.Context) ([]entity.Video, error) {
+	return s.videoRepository.FindAll(ctx)
 }
-func (s *videoService) FindAll() ([]entity.Video, error) {
-	return s.videoRepository.FindAll()
+
+func (s *videoService) FindByID(ctx context.Context, id uint64) (*entity.Video, error) {
+	return s.videoRepository.FindByID(ctx, id)
+}
+
+// Search normalizes paging, expands each category filter to its subtree and
+// runs the query.
+func (s *videoService) Search(ctx context.Context, filter repository.VideoFilter) (repository.VideoSearchResult, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+	filter.Tags = NormalizeTags(filter.Tags)
+
+	var categoryIDs []uint64
+	for _, id := range filter.CategoryIDs {
+		ids, err := s.taxonomyService.Subtree(ctx, id)
+		if err != nil {
+			return repository.VideoSearchResult{}, err
+		}
+		categoryIDs = append(categoryIDs, ids...)
+	}
+	filter.CategoryIDs = categoryIDs
+
+	return s.videoRepository.Search(ctx, filter)
 }
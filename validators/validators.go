@@ -2,6 +2,8 @@ package validators
 
 import (
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -9,3 +11,47 @@ import (
 func ValidateCoolTitle(field validator.FieldLevel) bool {
 	return strings.Contains(field.Field().String(), "Cool")
 }
+
+var (
+	blockedMu    sync.RWMutex
+	blockedWords = map[string]bool{
+		"fuck": true, "fucking": true, "shit": true, "bitch": true,
+		"asshole": true, "bastard": true, "cunt": true, "dick": true,
+	}
+)
+
+// SetBlockedWords replaces the keyword list used by the clean-text rule.
+func SetBlockedWords(words []string) {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			set[w] = true
+		}
+	}
+
+	blockedMu.Lock()
+	blockedWords = set
+	blockedMu.Unlock()
+}
+
+// ContainsBlockedWord reports whether any whole word of text is on the
+// blocked list, ignoring case and punctuation.
+func ContainsBlockedWord(text string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	blockedMu.RLock()
+	defer blockedMu.RUnlock()
+	for _, w := range words {
+		if blockedWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCleanText backs the "clean-text" tag used on user-written text.
+func ValidateCleanText(field validator.FieldLevel) bool {
+	return !ContainsBlockedWord(field.Field().String())
+}
@@ -0,0 +1,17 @@
+// Package web holds the server-rendered admin UI templates, embedded into
+// the binary so the app does not depend on files next to it at runtime.
+package web
+
+import (
+	"embed"
+	"html/template"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// Templates parses every embedded template. Pages are addressed by their
+// file name, e.g. "students.html".
+func Templates() (*template.Template, error) {
+	return template.New("").ParseFS(templateFS, "templates/*.html")
+}